@@ -9,8 +9,10 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/khanghh/contract-info/dasm"
+	"github.com/khanghh/contract-info/internal/cmdutil"
 	"github.com/urfave/cli/v2"
 )
 
@@ -27,10 +29,17 @@ var (
 
 var (
 	rpcUrlFlag = &cli.StringFlag{
-		Name:     "rpcurl",
-		Required: true,
-		EnvVars:  []string{"DASM_RPC_URL"},
-		Usage:    "ethereum JSON-RPC URLs to fetch the blockchain data",
+		Name:    "rpcurl",
+		EnvVars: []string{"DASM_RPC_URL"},
+		Usage:   "ethereum JSON-RPC URLs to fetch the blockchain data",
+	}
+	bytecodeFlag = &cli.StringFlag{
+		Name:  "bytecode",
+		Usage: "Bytecode in hex to disassemble instead of fetching it from the RPC",
+	}
+	fileFlag = &cli.StringFlag{
+		Name:  "file",
+		Usage: "File to read the bytecode from, hex or binary, \"-\" for the standard input",
 	}
 	outputDirFlag = &cli.StringFlag{
 		Name:    "outdir",
@@ -53,18 +62,35 @@ func init() {
 	app.Version = fmt.Sprintf("%s - %s ", gitCommit, gitDate)
 	app.Flags = []cli.Flag{
 		rpcUrlFlag,
+		bytecodeFlag,
+		fileFlag,
 		outputDirFlag,
 		verbosityFlag,
 	}
 }
 
-func mustInitRpcClient(cli *cli.Context) *rpc.Client {
+// initRpcClient dials the RPC given with --rpcurl, what tells what has to be fetched
+// from the chain.
+func initRpcClient(cli *cli.Context, what string) (*rpc.Client, error) {
 	rpcUrl := cli.String(rpcUrlFlag.Name)
+	if rpcUrl == "" {
+		return nil, fmt.Errorf("--rpcurl is required to fetch %s", what)
+	}
 	client, err := rpc.Dial(rpcUrl)
 	if err != nil {
-		panic(fmt.Errorf("could not dial RPC: %w", err))
+		return nil, fmt.Errorf("could not dial RPC: %w", err)
 	}
-	return client
+	return client, nil
+}
+
+// readOfflineBytecode reads the bytecode given with the command flags or piped to the
+// standard input, it returns nil if the bytecode has to be fetched from the RPC.
+func readOfflineBytecode(cli *cli.Context) ([]byte, error) {
+	return cmdutil.ReadBytecode(cmdutil.BytecodeInput{
+		Hex:   cli.String(bytecodeFlag.Name),
+		File:  cli.String(fileFlag.Name),
+		Stdin: cli.Args().Len() == 0,
+	})
 }
 
 func ethGetCode(client *rpc.Client, addr common.Address) ([]byte, error) {
@@ -92,23 +118,41 @@ func createDirIfNotExist(path string) error {
 func run(cli *cli.Context) error {
 	// ethcore.InitDefaultLogger(cli.Int(verbosityFlag.Name))
 	addrStr := cli.Args().Get(0)
-	if addrStr == "" {
-		return errors.New("must provide contract address")
+	bytecode, err := readOfflineBytecode(cli)
+	if err != nil {
+		return err
+	}
+	if addrStr == "" && bytecode == nil {
+		return errors.New("must provide contract address or bytecode")
 	}
 
-	client := mustInitRpcClient(cli)
-	defer client.Close()
+	// Offline bytecode is saved under its hash unless an address is given.
+	outName := crypto.Keccak256Hash(bytecode).Hex()
+	if addrStr != "" {
+		addr := common.HexToAddress(addrStr)
+		outName = hexutil.Encode(addr.Bytes())
+	}
+	if bytecode == nil {
+		client, err := initRpcClient(cli, "the contract bytecode")
+		if err != nil {
+			return err
+		}
+		defer client.Close()
 
-	addr := common.HexToAddress(addrStr)
-	fmt.Printf("Fetching contract bytecode for address %v\n", addr)
-	bytecode, err := ethGetCode(client, addr)
-	if err != nil {
-		return fmt.Errorf("could not get contract bytecode from rpc: %w", err)
+		addr := common.HexToAddress(addrStr)
+		fmt.Printf("Fetching contract bytecode for address %v\n", addr)
+		bytecode, err = ethGetCode(client, addr)
+		if err != nil {
+			return fmt.Errorf("could not get contract bytecode from rpc: %w", err)
+		}
 	}
 
+	if dasm.IsEOF(bytecode) {
+		fmt.Println("Detected EOF container")
+	}
 	instructions, err := dasm.Disassemble(bytecode)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to disaasemble contract bytecode %v", err))
+		return fmt.Errorf("could not disassemble bytecode: %w", err)
 	}
 
 	var dasmCode string
@@ -122,8 +166,8 @@ func run(cli *cli.Context) error {
 		panic(err)
 	}
 
-	dasmOutFile := path.Join(outputDir, fmt.Sprintf("%s.dasm", hexutil.Encode(addr.Bytes())))
-	binOutFile := path.Join(outputDir, fmt.Sprintf("%s.bin", hexutil.Encode(addr.Bytes())))
+	dasmOutFile := path.Join(outputDir, fmt.Sprintf("%s.dasm", outName))
+	binOutFile := path.Join(outputDir, fmt.Sprintf("%s.bin", outName))
 	if err := os.WriteFile(dasmOutFile, []byte(dasmCode), 0644); err != nil {
 		panic(fmt.Sprintf("Failed to write disassembled code to file: %v", err))
 	}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/khanghh/contract-info/dasm"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	abiOutFlag = &cli.StringFlag{
+		Name:    "out",
+		Aliases: []string{"o"},
+		Usage:   "Output file to save the reconstructed ABI, the standard output if not set",
+	}
+	abiCommand = &cli.Command{
+		Name:      "abi",
+		Usage:     "Reconstruct a best-effort ABI JSON of the contract",
+		ArgsUsage: "[address]",
+		Flags:     append(inputFlags, abiOutFlag),
+		Action:    runABI,
+	}
+)
+
+func runABI(cli *cli.Context) error {
+	logOut = os.Stderr
+	report, err := analyzeInput(cli)
+	if err != nil {
+		return err
+	}
+	interfaces, err := dasm.LoadInterfaces(cli.String(abisDirFlag.Name))
+	if err != nil {
+		return fmt.Errorf("could not parse interface abi: %w", err)
+	}
+	data, err := json.MarshalIndent(dasm.ReconstructABI(report, interfaces), "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode abi: %w", err)
+	}
+	if outFile := cli.String(abiOutFlag.Name); outFile != "" {
+		return os.WriteFile(outFile, data, 0644)
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/khanghh/contract-info/dasm"
+	"github.com/khanghh/contract-info/internal/cmdutil"
+	"github.com/urfave/cli/v2"
+)
+
+var decodeCalldataCommand = &cli.Command{
+	Name:      "decode-calldata",
+	Usage:     "Decode the input of a transaction against the functions of the ABIs",
+	ArgsUsage: "<calldata in hex, read from the standard input if neither set nor --tx>",
+	Flags:     []cli.Flag{rpcUrlFlag, txHashFlag, abisDirFlag, sigdbFlag, onlineSignaturesFlag},
+	Action:    runDecodeCalldata,
+}
+
+// readCalldata reads the calldata given as argument, piped to the standard input or the
+// input of the transaction fetched from the RPC.
+func readCalldata(cli *cli.Context) ([]byte, error) {
+	if cli.IsSet(txHashFlag.Name) {
+		client, err := initRpcClient(cli, "the transaction")
+		if err != nil {
+			return nil, err
+		}
+		defer client.Close()
+		tx, err := ethGetTransaction(client, common.HexToHash(cli.String(txHashFlag.Name)))
+		if err != nil {
+			return nil, fmt.Errorf("could not get transaction from rpc: %w", err)
+		}
+		return tx.Input, nil
+	}
+	input, err := cmdutil.ReadArgOrStdin(cli.Args().Get(0))
+	if errors.Is(err, cmdutil.ErrNoInput) {
+		return nil, errors.New("must provide calldata or transaction hash")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read calldata: %w", err)
+	}
+	data, err := cmdutil.DecodeHex(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid calldata: %w", err)
+	}
+	return data, nil
+}
+
+func runDecodeCalldata(cli *cli.Context) error {
+	data, err := readCalldata(cli)
+	if err != nil {
+		return err
+	}
+	interfaces, err := dasm.LoadInterfaces(cli.String(abisDirFlag.Name))
+	if err != nil {
+		return fmt.Errorf("could not parse interface abi: %w", err)
+	}
+	sources, err := signatureSources(cli)
+	if err != nil {
+		return err
+	}
+	calls, err := dasm.DecodeCalldata(context.Background(), data, interfaces, sources)
+	if err != nil {
+		return err
+	}
+	if len(calls) > 1 {
+		fmt.Printf("Selector %s is ambiguous, %d signatures decode the calldata\n", hexutil.Encode(data[:4]), len(calls))
+	}
+	for _, call := range calls {
+		fmt.Printf("Function: %s", call.Signature)
+		if len(call.Interfaces) > 0 {
+			fmt.Printf(" (%s)", strings.Join(call.Interfaces, ", "))
+		}
+		fmt.Println()
+		printArguments(os.Stdout, "  ", call.Inputs, call.Values)
+	}
+	return nil
+}
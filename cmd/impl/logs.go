@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/khanghh/contract-info/dasm"
+	"github.com/khanghh/contract-info/internal/cmdutil"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	receiptTxFlag = &cli.StringFlag{
+		Name:  "tx",
+		Usage: "Hash of the transaction to decode the receipt logs of",
+	}
+	fromBlockFlag = &cli.Uint64Flag{
+		Name:  "from-block",
+		Usage: "First block of the range to fetch the logs of with eth_getLogs",
+	}
+	toBlockFlag = &cli.Uint64Flag{
+		Name:  "to-block",
+		Usage: "Last block of the range to fetch the logs of, the latest block if not set",
+	}
+	logAddressFlag = &cli.StringFlag{
+		Name:  "address",
+		Usage: "Contract address to filter the logs of the block range",
+	}
+	logsFileFlag = &cli.StringFlag{
+		Name:  "file",
+		Usage: "JSON file of logs, a receipt or an eth_getLogs response, \"-\" for the standard input",
+	}
+	decodeLogsCommand = &cli.Command{
+		Name:   "decode-logs",
+		Usage:  "Decode event logs against the events of the ABIs",
+		Flags:  []cli.Flag{rpcUrlFlag, receiptTxFlag, fromBlockFlag, toBlockFlag, logAddressFlag, logsFileFlag, abisDirFlag},
+		Action: runDecodeLogs,
+	}
+)
+
+type rpcLog struct {
+	Address     common.Address `json:"address"`
+	Topics      []common.Hash  `json:"topics"`
+	Data        hexutil.Bytes  `json:"data"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	Index       hexutil.Uint   `json:"logIndex"`
+}
+
+// parseLogsJSON parses a list of logs, a receipt or a JSON-RPC response holding either.
+func parseLogsJSON(data []byte) ([]types.Log, error) {
+	var list []rpcLog
+	if err := json.Unmarshal(data, &list); err != nil {
+		var obj struct {
+			Logs   []rpcLog        `json:"logs"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, err
+		}
+		if obj.Result != nil {
+			return parseLogsJSON(obj.Result)
+		}
+		list = obj.Logs
+	}
+	logs := make([]types.Log, len(list))
+	for i, l := range list {
+		logs[i] = types.Log{
+			Address:     l.Address,
+			Topics:      l.Topics,
+			Data:        l.Data,
+			BlockNumber: uint64(l.BlockNumber),
+			TxHash:      l.TxHash,
+			Index:       uint(l.Index),
+		}
+	}
+	return logs, nil
+}
+
+// readLogs reads the logs of a JSON file, a transaction receipt or a block range.
+func readLogs(cli *cli.Context) ([]types.Log, error) {
+	if cli.IsSet(logsFileFlag.Name) {
+		data, err := cmdutil.ReadFileOrStdin(cli.String(logsFileFlag.Name))
+		if err != nil {
+			return nil, fmt.Errorf("could not read logs: %w", err)
+		}
+		logs, err := parseLogsJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse logs: %w", err)
+		}
+		return logs, nil
+	}
+	if !cli.IsSet(receiptTxFlag.Name) && !cli.IsSet(fromBlockFlag.Name) {
+		return nil, errors.New("must provide a logs file, a transaction hash or a block range")
+	}
+	rpcClient, err := initRpcClient(cli, "the logs")
+	if err != nil {
+		return nil, err
+	}
+	client := ethclient.NewClient(rpcClient)
+	defer client.Close()
+	if cli.IsSet(receiptTxFlag.Name) {
+		receipt, err := client.TransactionReceipt(context.Background(), common.HexToHash(cli.String(receiptTxFlag.Name)))
+		if err != nil {
+			return nil, fmt.Errorf("could not get transaction receipt from rpc: %w", err)
+		}
+		logs := make([]types.Log, len(receipt.Logs))
+		for i, l := range receipt.Logs {
+			logs[i] = *l
+		}
+		return logs, nil
+	}
+	query := ethereum.FilterQuery{FromBlock: new(big.Int).SetUint64(cli.Uint64(fromBlockFlag.Name))}
+	if cli.IsSet(toBlockFlag.Name) {
+		query.ToBlock = new(big.Int).SetUint64(cli.Uint64(toBlockFlag.Name))
+	}
+	if cli.IsSet(logAddressFlag.Name) {
+		query.Addresses = []common.Address{common.HexToAddress(cli.String(logAddressFlag.Name))}
+	}
+	logs, err := client.FilterLogs(context.Background(), query)
+	if err != nil {
+		return nil, fmt.Errorf("could not get logs from rpc: %w", err)
+	}
+	return logs, nil
+}
+
+func runDecodeLogs(cli *cli.Context) error {
+	logs, err := readLogs(cli)
+	if err != nil {
+		return err
+	}
+	interfaces, err := dasm.LoadInterfaces(cli.String(abisDirFlag.Name))
+	if err != nil {
+		return fmt.Errorf("could not parse interface abi: %w", err)
+	}
+	for _, log := range logs {
+		fmt.Printf("Log #%d %s (block %d, tx %s)\n", log.Index, log.Address.Hex(), log.BlockNumber, log.TxHash.Hex())
+		decoded, err := dasm.DecodeLog(&log, interfaces)
+		if err != nil {
+			if len(log.Topics) > 0 {
+				fmt.Printf("  Unknown event %s\n", log.Topics[0].Hex())
+			} else {
+				fmt.Println("  Unknown anonymous event")
+			}
+			continue
+		}
+		for _, event := range decoded {
+			fmt.Printf("  Event: %s (%s)", event.Signature, strings.Join(event.Interfaces, ", "))
+			if event.Anonymous {
+				fmt.Print(" anonymous, guessed from the topics")
+			}
+			fmt.Println()
+			printArguments(os.Stdout, "    ", event.Inputs, event.Values)
+		}
+	}
+	return nil
+}
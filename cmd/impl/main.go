@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -11,10 +14,13 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/khanghh/contract-info/dasm"
+	"github.com/khanghh/contract-info/internal/cmdutil"
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -26,20 +32,55 @@ var (
 	gitTag = ""
 	// The app that holds all commands and flags.
 	app *cli.App
+	// Writer of the progress messages.
+	logOut io.Writer = os.Stdout
 )
 
 var (
 	rpcUrlFlag = &cli.StringFlag{
-		Name:     "rpcurl",
-		Required: true,
-		EnvVars:  []string{"DASM_RPC_URL"},
-		Usage:    "ethereum JSON-RPC URLs to fetch the blockchain data",
+		Name:    "rpcurl",
+		EnvVars: []string{"DASM_RPC_URL"},
+		Usage:   "ethereum JSON-RPC URLs to fetch the blockchain data",
+	}
+	txHashFlag = &cli.StringFlag{
+		Name:  "tx",
+		Usage: "Hash of a contract creation transaction to analyse the deployed runtime code of",
+	}
+	initCodeFlag = &cli.StringFlag{
+		Name:  "initcode",
+		Usage: "Contract creation code in hex to analyse the deployed runtime code of",
+	}
+	bytecodeFlag = &cli.StringFlag{
+		Name:  "bytecode",
+		Usage: "Runtime bytecode in hex to analyse instead of fetching it from the RPC",
+	}
+	fileFlag = &cli.StringFlag{
+		Name:  "file",
+		Usage: "File to read the runtime bytecode from, hex or binary, \"-\" for the standard input",
 	}
 	abisDirFlag = &cli.StringFlag{
 		Name:  "abis",
 		Value: "abis",
 		Usage: "ABIs directory to load the contract interfaces",
 	}
+	formatFlag = &cli.StringFlag{
+		Name:  "format",
+		Value: "table",
+		Usage: "Output format: table, json or yaml",
+	}
+	selectorModeFlag = &cli.StringFlag{
+		Name:  "selector-mode",
+		Value: "pattern",
+		Usage: "Selector extraction method: pattern (dispatcher patterns), emulate (stack emulation) or both",
+	}
+	sigdbFlag = &cli.StringFlag{
+		Name:  "sigdb",
+		Usage: "Signature database file to resolve the selectors and topics unknown to the ABIs, before the bundled database",
+	}
+	onlineSignaturesFlag = &cli.BoolFlag{
+		Name:  "online-signatures",
+		Usage: "Look up the selectors and topics unknown to the ABIs in the openchain.xyz signature database",
+	}
 	verbosityFlag = &cli.IntFlag{
 		Name:    "verbosity",
 		Usage:   "Log verbosity level (0-5)",
@@ -48,56 +89,75 @@ var (
 	}
 )
 
+// inputFlags select the contract to analyse, shared by the commands.
+var inputFlags = []cli.Flag{
+	rpcUrlFlag,
+	txHashFlag,
+	initCodeFlag,
+	bytecodeFlag,
+	fileFlag,
+	abisDirFlag,
+	selectorModeFlag,
+	sigdbFlag,
+	onlineSignaturesFlag,
+}
+
 func init() {
 	app = cli.NewApp()
 	app.Action = run
 	app.Name = filepath.Base(os.Args[0])
 	app.Usage = fmt.Sprintf("Ethereum contract parser %s", gitTag)
 	app.Version = fmt.Sprintf("%s - %s ", gitCommit, gitDate)
-	app.Flags = []cli.Flag{
-		rpcUrlFlag,
-		abisDirFlag,
-		verbosityFlag,
+	app.Flags = append(inputFlags, formatFlag, verbosityFlag)
+	app.Commands = []*cli.Command{
+		abiCommand,
+		decodeRevertCommand,
+		decodeCalldataCommand,
+		decodeLogsCommand,
 	}
 }
 
-func mustInitRpcClient(cli *cli.Context) *rpc.Client {
+// initRpcClient dials the RPC given with --rpcurl, what tells what has to be fetched
+// from the chain.
+func initRpcClient(cli *cli.Context, what string) (*rpc.Client, error) {
 	rpcUrl := cli.String(rpcUrlFlag.Name)
+	if rpcUrl == "" {
+		return nil, fmt.Errorf("--rpcurl is required to fetch %s", what)
+	}
 	client, err := rpc.Dial(rpcUrl)
 	if err != nil {
-		panic(fmt.Errorf("could not dial RPC: %w", err))
+		return nil, fmt.Errorf("could not dial RPC: %w", err)
 	}
-	return client
+	return client, nil
 }
 
-func ethGetCode(client *rpc.Client, addr common.Address) ([]byte, error) {
-	var result hexutil.Bytes
-	err := client.Call(&result, "eth_getCode", addr, "latest")
-	if err != nil {
-		return nil, err
-	}
-	return result, nil
+// readOfflineBytecode reads the bytecode given with the command flags or piped to the
+// standard input, it returns nil if the bytecode has to be fetched from the RPC.
+func readOfflineBytecode(cli *cli.Context) ([]byte, error) {
+	return cmdutil.ReadBytecode(cmdutil.BytecodeInput{
+		Hex:   cli.String(bytecodeFlag.Name),
+		File:  cli.String(fileFlag.Name),
+		Stdin: cli.Args().Len() == 0,
+	})
 }
 
-func ethStorageAt(client *rpc.Client, addr common.Address, slot common.Hash) ([]byte, error) {
-	var result hexutil.Bytes
-	err := client.Call(&result, "eth_getStorageAt", addr, slot, "latest")
-	if err != nil {
-		return nil, err
-	}
-	return result, nil
+type rpcTransaction struct {
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to"`
+	Nonce hexutil.Uint64  `json:"nonce"`
+	Input hexutil.Bytes   `json:"input"`
 }
 
-func getProxyImplementation(client *rpc.Client, proxyAddr common.Address) (common.Address, error) {
-	implementationSlot := crypto.Keccak256Hash([]byte("eip1967.proxy.implementation"))
-	implementationSlot[len(implementationSlot)-1] = implementationSlot[len(implementationSlot)-1] - 1
-	ret, err := ethStorageAt(client, proxyAddr, implementationSlot)
+func ethGetTransaction(client *rpc.Client, hash common.Hash) (*rpcTransaction, error) {
+	var result *rpcTransaction
+	err := client.Call(&result, "eth_getTransactionByHash", hash)
 	if err != nil {
-		return common.Address{}, nil
+		return nil, err
 	}
-	var implAddr common.Address
-	copy(implAddr[:], ret[12:])
-	return implAddr, nil
+	if result == nil {
+		return nil, errors.New("transaction not found")
+	}
+	return result, nil
 }
 
 func printContractInfo(data [][]string) {
@@ -112,80 +172,244 @@ func printContractInfo(data [][]string) {
 	table.Render()
 }
 
-func renderMethodList(methodIDsMap map[string][]string) string {
+func renderMethodList(selectors []dasm.SelectorReport) string {
 	methodList := make([]string, 0)
-	for methodID, methodSigs := range methodIDsMap {
-		methodList = append(methodList, fmt.Sprintf("- %s %s", methodID, strings.Join(methodSigs, ",")))
+	for _, sel := range selectors {
+		sigs := strings.Join(sel.Signatures, ",")
+		if sigs == "" && sel.Inferred != "" {
+			sigs = sel.Inferred + "?"
+		}
+		if sel.StateMutability != "" {
+			sigs += " " + sel.StateMutability
+		}
+		methodList = append(methodList, fmt.Sprintf("- %s %s", sel.Selector, sigs))
 	}
 	return strings.Join(methodList, "\n")
 }
 
-func renderInterfaceList(interfaceNames []string) string {
+func renderEventList(events []dasm.EventReport) string {
+	eventList := make([]string, 0)
+	for _, event := range events {
+		eventList = append(eventList, fmt.Sprintf("- %s %s", event.Topic, strings.Join(event.Signatures, ",")))
+	}
+	return strings.Join(eventList, "\n")
+}
+
+func renderErrorList(errors []dasm.ErrorReport) string {
+	errorList := make([]string, 0)
+	for _, e := range errors {
+		errorList = append(errorList, fmt.Sprintf("- %s %s", e.Selector, strings.Join(e.Signatures, ",")))
+	}
+	return strings.Join(errorList, "\n")
+}
+
+func renderReasonList(reasons []string) string {
+	reasonList := make([]string, 0)
+	for _, reason := range reasons {
+		reasonList = append(reasonList, fmt.Sprintf("- %q", reason))
+	}
+	return strings.Join(reasonList, "\n")
+}
+
+func renderWords(data []byte) string {
+	words := make([]string, 0)
+	for i := 0; i < len(data); i += 32 {
+		words = append(words, hexutil.Encode(data[i:min(i+32, len(data))]))
+	}
+	return strings.Join(words, "\n")
+}
+
+func renderInterfaceList(interfaces []dasm.InterfaceReport) string {
 	interfaceList := make([]string, 0)
-	for _, intfName := range interfaceNames {
-		interfaceList = append(interfaceList, fmt.Sprintf("- %s", intfName))
+	for _, intf := range interfaces {
+		if intf.Confidence == 1 {
+			interfaceList = append(interfaceList, fmt.Sprintf("- %s", intf.Name))
+		}
 	}
 	return strings.Join(interfaceList, "\n")
 }
 
-func getContractInterfaces(intefaces []dasm.Interface, sigs []string) []string {
-	ret := make([]string, 0)
-	for _, intf := range intefaces {
-		if dasm.IsImplement(intf, sigs) {
-			ret = append(ret, intf.Name)
+func printReportTable(report *dasm.Report) {
+	infos := make([][]string, 0)
+	if report.Address != "" {
+		infos = append(infos, []string{"Address", report.Address})
+	}
+	if report.Creation != nil {
+		infos = append(infos, []string{"Runtime Code", fmt.Sprintf("%d bytes at offset %d", report.Creation.RuntimeSize, report.Creation.RuntimeOffset)})
+		if report.Creation.ConstructorArgs != "" {
+			infos = append(infos, []string{"Constructor Arguments", renderWords(common.FromHex(report.Creation.ConstructorArgs))})
+		}
+	}
+	infos = append(infos, []string{"Bytecode Format", report.Format})
+	if report.Metadata != nil {
+		metadata := dasm.Metadata{
+			Compiler:     dasm.Compiler(report.Metadata.Compiler),
+			Version:      report.Metadata.Version,
+			IPFS:         report.Metadata.IPFS,
+			Swarm:        report.Metadata.Swarm,
+			Experimental: report.Metadata.Experimental,
+		}
+		infos = append(infos, []string{"Metadata", metadata.String()})
+	}
+	infos = append(infos, []string{"Compiler", fmt.Sprintf("%s %s (optimizer: %s)", report.Compiler.Name, report.Compiler.Version, report.Compiler.Optimizer)})
+	infos = append(infos, []string{"Is Proxy Contract", strconv.FormatBool(report.Proxy.IsProxy)})
+	if report.Proxy.Implementation != "" {
+		infos = append(infos, []string{"Implementation Address", report.Proxy.Implementation})
+	}
+	infos = append(infos, []string{"Poissible Methods", renderMethodList(report.Selectors)})
+	infos = append(infos, []string{"Poissible Events", renderEventList(report.Events)})
+	if len(report.Errors) > 0 {
+		infos = append(infos, []string{"Possible Errors", renderErrorList(report.Errors)})
+	}
+	if len(report.Reasons) > 0 {
+		infos = append(infos, []string{"Revert Reasons", renderReasonList(report.Reasons)})
+	}
+	if interfaceList := renderInterfaceList(report.Interfaces); interfaceList != "" {
+		infos = append(infos, []string{"Possible Interfaces", interfaceList})
+	}
+	printContractInfo(infos)
+}
+
+func printReport(format string, report *dasm.Report) error {
+	switch format {
+	case "table":
+		printReportTable(report)
+		return nil
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "yaml":
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		defer encoder.Close()
+		return encoder.Encode(report)
+	}
+	return fmt.Errorf("invalid output format %s", format)
+}
+
+// signatureSources returns the signature database file if any and the bundled database,
+// followed by openchain.xyz if the online lookups are enabled.
+func signatureSources(cli *cli.Context) ([]dasm.SignatureSource, error) {
+	sources := make([]dasm.SignatureSource, 0)
+	if cli.IsSet(sigdbFlag.Name) {
+		db, err := dasm.LoadSignatureDB(cli.String(sigdbFlag.Name))
+		if err != nil {
+			return nil, fmt.Errorf("could not load signature database: %w", err)
 		}
+		sources = append(sources, db)
 	}
-	return ret
+	sources = append(sources, dasm.BundledSignatureDB())
+	if cli.Bool(onlineSignaturesFlag.Name) {
+		sources = append(sources, dasm.NewOpenchainSource())
+	}
+	return sources, nil
 }
 
-func run(cli *cli.Context) error {
+// analyzeInput analyses the contract given by the address, the offline bytecode or the
+// init code flags.
+func analyzeInput(cli *cli.Context) (*dasm.Report, error) {
 	addrStr := cli.Args().Get(0)
-	if addrStr == "" {
-		return errors.New("must provide contract address")
+	var offline, initcode []byte
+	if cli.IsSet(initCodeFlag.Name) {
+		var err error
+		if initcode, err = cmdutil.DecodeHex([]byte(cli.String(initCodeFlag.Name))); err != nil {
+			return nil, fmt.Errorf("invalid init code: %w", err)
+		}
+	}
+	if !cli.IsSet(txHashFlag.Name) && !cli.IsSet(initCodeFlag.Name) {
+		var err error
+		if offline, err = readOfflineBytecode(cli); err != nil {
+			return nil, err
+		}
+	}
+	if addrStr == "" && offline == nil && !cli.IsSet(txHashFlag.Name) && !cli.IsSet(initCodeFlag.Name) {
+		return nil, errors.New("must provide contract address or bytecode")
+	}
+	var client *rpc.Client
+	if offline == nil && !cli.IsSet(initCodeFlag.Name) {
+		what := "the contract bytecode"
+		if cli.IsSet(txHashFlag.Name) {
+			what = "the contract creation transaction"
+		}
+		var err error
+		if client, err = initRpcClient(cli, what); err != nil {
+			return nil, err
+		}
+		defer client.Close()
 	}
 
 	interfaces, err := dasm.LoadInterfaces(cli.String(abisDirFlag.Name))
 	if err != nil {
-		return fmt.Errorf("could not parse interface abi: %w", err)
+		return nil, fmt.Errorf("could not parse interface abi: %w", err)
 	}
-	fmt.Printf("Loaded %d interface ABIs\n", len(interfaces))
-
-	client := mustInitRpcClient(cli)
-	defer client.Close()
+	fmt.Fprintf(logOut, "Loaded %d interface ABIs\n", len(interfaces))
 
-	addr := common.HexToAddress(addrStr)
-	fmt.Println("Fetching contract bytecode...")
-	bytecode, err := ethGetCode(client, addr)
+	sources, err := signatureSources(cli)
 	if err != nil {
-		return fmt.Errorf("could not get contract bytecode from rpc: %w", err)
+		return nil, err
+	}
+	config := dasm.AnalyzerConfig{
+		Interfaces:   interfaces,
+		Signatures:   sources,
+		SelectorMode: dasm.SelectorMode(cli.String(selectorModeFlag.Name)),
 	}
+	if client != nil {
+		config.Fetcher = ethclient.NewClient(client)
+	}
+	analyzer := dasm.NewAnalyzer(config)
 
-	isProxy := dasm.IsProxy(bytecode)
-	infos := make([][]string, 0)
-	infos = append(infos, []string{"Address", addr.Hex()})
-	infos = append(infos, []string{"Is Proxy Contract", strconv.FormatBool(isProxy)})
-	if isProxy {
-		proxyImplAddr, err := getProxyImplementation(client, addr)
-		if err == nil && (proxyImplAddr != common.Address{}) {
-			infos = append(infos, []string{"Implementation Address", proxyImplAddr.Hex()})
+	var report *dasm.Report
+	switch {
+	case offline != nil:
+		if report, err = analyzer.AnalyzeBytecode(offline); err == nil && addrStr != "" {
+			report.Address = common.HexToAddress(addrStr).Hex()
+		}
+	case cli.IsSet(initCodeFlag.Name):
+		report, err = analyzer.AnalyzeInitCode(initcode)
+	case cli.IsSet(txHashFlag.Name):
+		fmt.Fprintln(logOut, "Fetching contract creation transaction...")
+		tx, err := ethGetTransaction(client, common.HexToHash(cli.String(txHashFlag.Name)))
+		if err != nil {
+			return nil, fmt.Errorf("could not get transaction from rpc: %w", err)
+		}
+		if tx.To != nil {
+			return nil, fmt.Errorf("transaction %s is not a contract creation", cli.String(txHashFlag.Name))
+		}
+		if report, err = analyzer.AnalyzeInitCode(tx.Input); err != nil {
+			return nil, fmt.Errorf("could not analyse init code: %w", err)
 		}
+		report.Address = crypto.CreateAddress(tx.From, uint64(tx.Nonce)).Hex()
+	default:
+		fmt.Fprintln(logOut, "Fetching contract bytecode...")
+		report, err = analyzer.Analyze(context.Background(), common.HexToAddress(addrStr))
 	}
-
-	methodIDs := dasm.ParseFunctionSelectors(bytecode)
-	methodIDsMap := make(map[string][]string)
-	for _, methodID := range methodIDs {
-		methodIDsMap[methodID] = dasm.GetMethodSigsByID(methodID, interfaces)
+	if err != nil {
+		return nil, err
 	}
-	infos = append(infos, []string{"Poissible Methods", renderMethodList(methodIDsMap)})
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	return report, nil
+}
 
-	topics := dasm.ParseEventTopics(bytecode)
-	infos = append(infos, []string{"Poissible Events", strings.Join(topics, "\n")})
-	contractInterfaces := getContractInterfaces(interfaces, append(methodIDs, topics...))
-	if len(contractInterfaces) > 0 {
-		infos = append(infos, []string{"Possible Interfaces", renderInterfaceList(contractInterfaces)})
+func run(cli *cli.Context) error {
+	format := cli.String(formatFlag.Name)
+	switch format {
+	case "table", "json", "yaml":
+	default:
+		// Fail before fetching and analysing anything.
+		return fmt.Errorf("invalid output format %s", format)
 	}
-	printContractInfo(infos)
-	return nil
+	if format != "table" {
+		// Keep the standard output parsable, progress goes to the standard error.
+		logOut = os.Stderr
+	}
+	report, err := analyzeInput(cli)
+	if err != nil {
+		return err
+	}
+	return printReport(format, report)
 }
 
 func main() {
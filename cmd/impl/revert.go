@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/khanghh/contract-info/dasm"
+	"github.com/khanghh/contract-info/internal/cmdutil"
+	"github.com/urfave/cli/v2"
+)
+
+var decodeRevertCommand = &cli.Command{
+	Name:      "decode-revert",
+	Usage:     "Decode the data of a reverted call: Error(string), Panic(uint256) or custom errors of the ABIs",
+	ArgsUsage: "<revert data in hex, read from the standard input if not set>",
+	Flags:     []cli.Flag{abisDirFlag},
+	Action:    runDecodeRevert,
+}
+
+func runDecodeRevert(cli *cli.Context) error {
+	input, err := cmdutil.ReadArgOrStdin(cli.Args().Get(0))
+	if errors.Is(err, cmdutil.ErrNoInput) {
+		return errors.New("must provide revert data")
+	}
+	if err != nil {
+		return fmt.Errorf("could not read revert data: %w", err)
+	}
+	data, err := cmdutil.DecodeHex(input)
+	if err != nil {
+		return fmt.Errorf("invalid revert data: %w", err)
+	}
+	interfaces, err := dasm.LoadInterfaces(cli.String(abisDirFlag.Name))
+	if err != nil {
+		return fmt.Errorf("could not parse interface abi: %w", err)
+	}
+	decoded, err := dasm.DecodeRevert(data, interfaces)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Error: %s\n", decoded.Signature)
+	printArguments(os.Stdout, "  ", decoded.Inputs, decoded.Values)
+	if decoded.Reason != "" {
+		fmt.Printf("Reason: %s\n", decoded.Reason)
+	}
+	return nil
+}
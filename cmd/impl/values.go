@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// isNested tells whether values of the type are printed over several lines.
+func isNested(typ abi.Type) bool {
+	switch typ.T {
+	case abi.TupleTy:
+		return true
+	case abi.SliceTy, abi.ArrayTy:
+		return isNested(*typ.Elem)
+	}
+	return false
+}
+
+// formatValue formats a decoded value of a type which is not nested.
+func formatValue(typ abi.Type, val reflect.Value) string {
+	switch typ.T {
+	case abi.AddressTy:
+		return val.Interface().(common.Address).Hex()
+	case abi.StringTy:
+		return fmt.Sprintf("%q", val.String())
+	case abi.BytesTy:
+		return hexutil.Encode(val.Bytes())
+	case abi.FixedBytesTy:
+		buf := make([]byte, val.Len())
+		reflect.Copy(reflect.ValueOf(buf), val)
+		return hexutil.Encode(buf)
+	case abi.SliceTy, abi.ArrayTy:
+		elems := make([]string, val.Len())
+		for i := range elems {
+			elems[i] = formatValue(*typ.Elem, val.Index(i))
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	}
+	return fmt.Sprint(val.Interface())
+}
+
+// printValue prints a decoded value with its name and type, the fields of the tuples and
+// the elements of the arrays of tuples are printed below, indented.
+func printValue(w io.Writer, indent string, name string, typ abi.Type, val reflect.Value) {
+	if hash, ok := val.Interface().(common.Hash); ok {
+		// Indexed event arguments of dynamic types are known by the hash of their value only.
+		fmt.Fprintf(w, "%s%s %s: %s (hash)\n", indent, name, typ.String(), hash.Hex())
+		return
+	}
+	if !isNested(typ) {
+		fmt.Fprintf(w, "%s%s %s: %s\n", indent, name, typ.String(), formatValue(typ, val))
+		return
+	}
+	fmt.Fprintf(w, "%s%s %s:\n", indent, name, typ.String())
+	switch typ.T {
+	case abi.TupleTy:
+		for i, elem := range typ.TupleElems {
+			printValue(w, indent+"  ", typ.TupleRawNames[i], *elem, val.Field(i))
+		}
+	case abi.SliceTy, abi.ArrayTy:
+		for i := 0; i < val.Len(); i++ {
+			printValue(w, indent+"  ", fmt.Sprintf("[%d]", i), *typ.Elem, val.Index(i))
+		}
+	}
+}
+
+// printArguments prints the decoded values of the arguments, unnamed arguments are
+// named after their position.
+func printArguments(w io.Writer, indent string, args abi.Arguments, values []interface{}) {
+	for i, arg := range args {
+		name := arg.Name
+		if name == "" {
+			name = fmt.Sprintf("[%d]", i)
+		}
+		printValue(w, indent, name, arg.Type, reflect.ValueOf(values[i]))
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/khanghh/contract-info/dasm"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	// Git SHA1 commit hash of the release (set via linker flags)
+	gitCommit = ""
+	// The date of the release (set via linker flags)
+	gitDate = ""
+	// The version of the release (set via linker flags)
+	gitTag = ""
+	// The app that holds all commands and flags.
+	app *cli.App
+)
+
+var (
+	dbFileFlag = &cli.StringFlag{
+		Name:  "db",
+		Value: "signatures.db",
+		Usage: "Signature database file",
+	}
+	bundledFlag = &cli.BoolFlag{
+		Name:  "bundled",
+		Usage: "Use the database bundled in the binary instead of the database file",
+	}
+	formatFlag = &cli.StringFlag{
+		Name:  "format",
+		Value: "json",
+		Usage: "Output format: json or csv",
+	}
+	outFlag = &cli.StringFlag{
+		Name:    "out",
+		Aliases: []string{"o"},
+		Usage:   "File to write the signatures to instead of the standard output",
+	}
+	importCommand = &cli.Command{
+		Name:      "import",
+		Usage:     "Import signatures into the database, created if it does not exist",
+		ArgsUsage: "<file or directory>...",
+		Description: "Files are text signature lists, 4byte.directory and openchain dumps, ABI JSON files,\n" +
+			"Foundry or Hardhat artifacts, JSON exports or other databases. Directories such as\n" +
+			"Foundry's out/ or Hardhat's artifacts/ are searched for ABIs and artifacts.",
+		Flags:  []cli.Flag{dbFileFlag},
+		Action: runImport,
+	}
+	exportCommand = &cli.Command{
+		Name:   "export",
+		Usage:  "Export the signatures of the database",
+		Flags:  []cli.Flag{dbFileFlag, bundledFlag, formatFlag, outFlag},
+		Action: runExport,
+	}
+)
+
+func init() {
+	app = cli.NewApp()
+	app.Name = filepath.Base(os.Args[0])
+	app.Usage = fmt.Sprintf("Ethereum signature database manager %s", gitTag)
+	app.Version = fmt.Sprintf("%s - %s ", gitCommit, gitDate)
+	app.Commands = []*cli.Command{
+		importCommand,
+		exportCommand,
+	}
+}
+
+// loadDB loads the database file, or returns an empty database if it does not exist.
+func loadDB(fileName string) (*dasm.SignatureDB, error) {
+	db, err := dasm.LoadSignatureDB(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return dasm.NewSignatureDB(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not load signature database: %w", err)
+	}
+	return db, nil
+}
+
+func printSizes(db *dasm.SignatureDB) {
+	fmt.Printf("Database has %d functions, %d events and %d errors\n",
+		db.Len(dasm.KindFunction), db.Len(dasm.KindEvent), db.Len(dasm.KindError))
+}
+
+func runImport(cli *cli.Context) error {
+	if cli.Args().Len() == 0 {
+		return errors.New("must provide files to import")
+	}
+	dbFile := cli.String(dbFileFlag.Name)
+	db, err := loadDB(dbFile)
+	if err != nil {
+		return err
+	}
+	for _, fileName := range cli.Args().Slice() {
+		added, skipped, err := db.ImportFile(fileName)
+		if err != nil {
+			return fmt.Errorf("could not import %s: %w", fileName, err)
+		}
+		fmt.Printf("Imported %d new signatures from %s\n", added, fileName)
+		if skipped > 0 {
+			fmt.Printf("Skipped %d JSON files of %s that could not be parsed\n", skipped, fileName)
+		}
+	}
+	if err := dasm.SaveSignatureDB(db, dbFile); err != nil {
+		return fmt.Errorf("could not save signature database: %w", err)
+	}
+	printSizes(db)
+	return nil
+}
+
+func writeEntries(w io.Writer, format string, entries []dasm.SignatureEntry) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"kind", "id", "signature"})
+		for _, entry := range entries {
+			cw.Write([]string{entry.Kind.String(), entry.ID, entry.Signature})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unsupported output format %q", format)
+}
+
+func runExport(cli *cli.Context) error {
+	db := dasm.BundledSignatureDB()
+	if !cli.Bool(bundledFlag.Name) {
+		var err error
+		if db, err = dasm.LoadSignatureDB(cli.String(dbFileFlag.Name)); err != nil {
+			return fmt.Errorf("could not load signature database: %w", err)
+		}
+	}
+	out := io.Writer(os.Stdout)
+	if cli.IsSet(outFlag.Name) {
+		f, err := os.Create(cli.String(outFlag.Name))
+		if err != nil {
+			return fmt.Errorf("could not create output file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	return writeEntries(out, cli.String(formatFlag.Name), db.Entries())
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,150 @@
+package dasm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// splitTypes splits a comma separated list of types at the top level, ignoring the
+// commas between the parentheses of tuples.
+func splitTypes(list string) ([]string, error) {
+	if list == "" {
+		return nil, nil
+	}
+	types := make([]string, 0)
+	depth, start := 0, 0
+	for i, c := range list {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in %q", list)
+			}
+		case ',':
+			if depth == 0 {
+				types = append(types, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses in %q", list)
+	}
+	return append(types, list[start:]), nil
+}
+
+// typeMarshaling converts a canonical type, e.g. `(uint256,address)[]`, to the ABI JSON
+// form where tuples are described by their components.
+func typeMarshaling(typ string) (abi.ArgumentMarshaling, error) {
+	typ = strings.TrimSpace(typ)
+	if !strings.HasPrefix(typ, "(") {
+		return abi.ArgumentMarshaling{Type: typ}, nil
+	}
+	end := strings.LastIndex(typ, ")")
+	elems, err := splitTypes(typ[1:end])
+	if err != nil {
+		return abi.ArgumentMarshaling{}, err
+	}
+	ret := abi.ArgumentMarshaling{Type: "tuple" + typ[end+1:]}
+	for i, elem := range elems {
+		component, err := typeMarshaling(elem)
+		if err != nil {
+			return abi.ArgumentMarshaling{}, err
+		}
+		// Anonymous tuple fields are not supported by the abi package.
+		component.Name = fmt.Sprintf("field%d", i)
+		ret.Components = append(ret.Components, component)
+	}
+	return ret, nil
+}
+
+// ParseSignature parses a canonical signature like `transfer(address,uint256)` into its
+// name and unnamed arguments.
+func ParseSignature(sig string) (string, abi.Arguments, error) {
+	open := strings.Index(sig, "(")
+	if open <= 0 || !strings.HasSuffix(sig, ")") {
+		return "", nil, fmt.Errorf("invalid signature %q", sig)
+	}
+	types, err := splitTypes(sig[open+1 : len(sig)-1])
+	if err != nil {
+		return "", nil, err
+	}
+	args := make(abi.Arguments, 0, len(types))
+	for _, typ := range types {
+		marshaling, err := typeMarshaling(typ)
+		if err != nil {
+			return "", nil, err
+		}
+		abiType, err := abi.NewType(marshaling.Type, "", marshaling.Components)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid signature %q: %w", sig, err)
+		}
+		args = append(args, abi.Argument{Type: abiType})
+	}
+	return sig[:open], args, nil
+}
+
+// interfaceElement returns the element of the id declared by the first interface having it.
+func interfaceElement(typ string, id string, interfaces []Interface) (ABIElement, bool) {
+	for _, intf := range interfaces {
+		elements := intf.Elements
+		if typ == "error" {
+			elements = intf.ErrorElements
+		}
+		if elem, ok := elements[id]; ok && elem.Type == typ {
+			return elem, true
+		}
+	}
+	return ABIElement{}, false
+}
+
+// newABIElement returns the element declared by the interfaces, with its argument names,
+// indexed flags and outputs, or the element of the first parsable signature from a
+// signature source. A placeholder named after the id without arguments is returned if
+// none of the signatures is known.
+func newABIElement(typ string, id string, sigs []string, interfaces []Interface) ABIElement {
+	if elem, ok := interfaceElement(typ, id, interfaces); ok {
+		return elem
+	}
+	for _, sig := range sigs {
+		name, args, err := ParseSignature(sig)
+		if err == nil {
+			return ABIElement{Type: typ, Name: name, Inputs: args}
+		}
+	}
+	return ABIElement{Type: typ, Name: "unknown_0x" + id}
+}
+
+// ReconstructABI builds a best-effort ABI of the analysed contract. Functions, events and
+// custom errors declared by the interfaces the report was analysed with are copied as is,
+// the others take their first known signature. The unknown ones are named after their
+// selector or topic, e.g. `unknown_0x313ce567`, with the inferred arguments if any.
+// Functions not declared by the interfaces have the state mutability inferred from their body.
+func ReconstructABI(report *Report, interfaces []Interface) []ABIElement {
+	elems := make([]ABIElement, 0, len(report.Selectors)+len(report.Events)+len(report.Errors))
+	for _, sel := range report.Selectors {
+		if elem, ok := interfaceElement("function", sel.Selector, interfaces); ok {
+			elems = append(elems, elem)
+			continue
+		}
+		elem := newABIElement("function", sel.Selector, sel.Signatures, nil)
+		if len(sel.Signatures) == 0 && sel.Inferred != "" {
+			if _, args, err := ParseSignature(sel.Inferred); err == nil {
+				elem.Inputs = args
+			}
+		}
+		elem.StateMutability = sel.StateMutability
+		elems = append(elems, elem)
+	}
+	for _, event := range report.Events {
+		elems = append(elems, newABIElement("event", event.Topic, event.Signatures, interfaces))
+	}
+	for _, e := range report.Errors {
+		elems = append(elems, newABIElement("error", e.Selector, e.Signatures, interfaces))
+	}
+	return elems
+}
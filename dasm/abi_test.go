@@ -0,0 +1,67 @@
+package dasm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+func TestReconstructABI(t *testing.T) {
+	report := &Report{
+		Selectors: []SelectorReport{
+			{Selector: "313ce567", Inferred: "f(uint256,address)", StateMutability: MutabilityView},
+			{Selector: FourBytesSigOf("swap((uint256,address)[],bytes)"), Signatures: []string{"swap((uint256,address)[],bytes)"}},
+		},
+		Events: []EventReport{{Topic: "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", Signatures: []string{"Transfer(address,address,uint256)"}}},
+		Errors: []ErrorReport{{Selector: FourBytesSigOf("InsufficientBalance(uint256,uint256)"), Signatures: []string{"InsufficientBalance(uint256,uint256)"}}},
+	}
+	data, err := json.Marshal(ReconstructABI(report, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("could not load reconstructed abi: %v\n%s", err, data)
+	}
+	if unknown, ok := parsed.Methods["unknown_0x313ce567"]; !ok || len(unknown.Inputs) != 2 || unknown.Inputs[1].Type.String() != "address" || unknown.StateMutability != MutabilityView {
+		t.Errorf("missing placeholder for unknown selector: %s", data)
+	}
+	swap, ok := parsed.Methods["swap"]
+	if !ok || hex.EncodeToString(swap.ID) != report.Selectors[1].Selector {
+		t.Errorf("unexpected swap method %v", swap)
+	}
+	if event := parsed.Events["Transfer"]; event.ID.Hex()[2:] != report.Events[0].Topic {
+		t.Errorf("unexpected Transfer event %v", event)
+	}
+	if e, ok := parsed.Errors["InsufficientBalance"]; !ok || hex.EncodeToString(e.ID[:4]) != report.Errors[0].Selector {
+		t.Errorf("unexpected error %v", e)
+	}
+}
+
+func TestReconstructABIFromInterfaces(t *testing.T) {
+	intf := loadInterface(t, "Token", `[
+		{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable"},
+		{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}],"anonymous":false}
+	]`)
+	report := &Report{
+		Selectors: []SelectorReport{{Selector: "a9059cbb", Signatures: []string{"transfer(address,uint256)"}, StateMutability: MutabilityPayable}},
+		Events:    []EventReport{{Topic: "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", Signatures: []string{"Transfer(address,address,uint256)"}}},
+	}
+	data, err := json.Marshal(ReconstructABI(report, []Interface{intf}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("could not load reconstructed abi: %v\n%s", err, data)
+	}
+	if transfer := parsed.Methods["transfer"]; len(transfer.Outputs) != 1 || transfer.Inputs[0].Name != "to" || transfer.StateMutability != MutabilityNonPayable {
+		t.Errorf("unexpected transfer method %s", data)
+	}
+	if event := parsed.Events["Transfer"]; len(event.Inputs) != 3 || !event.Inputs[0].Indexed || !event.Inputs[1].Indexed || event.Inputs[2].Indexed || event.Inputs[0].Name != "from" {
+		t.Errorf("unexpected Transfer event %s", data)
+	}
+}
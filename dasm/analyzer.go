@@ -0,0 +1,325 @@
+package dasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SelectorMode is the method used to extract the function selectors.
+type SelectorMode string
+
+const (
+	SelectorModePattern SelectorMode = "pattern" // match the dispatcher patterns
+	SelectorModeEmulate SelectorMode = "emulate" // emulate the dispatcher
+	SelectorModeBoth    SelectorMode = "both"    // union of both methods
+)
+
+// eip1967ImplementationSlot is the storage slot of the implementation address of EIP-1967 proxies.
+var eip1967ImplementationSlot = common.BigToHash(new(big.Int).Sub(crypto.Keccak256Hash([]byte("eip1967.proxy.implementation")).Big(), common.Big1))
+
+// SignatureSource resolves function selectors and event topics to their text signatures.
+type SignatureSource interface {
+	FunctionSignatures(ctx context.Context, selector string) ([]string, error)
+	EventSignatures(ctx context.Context, topic string) ([]string, error)
+}
+
+// ErrorSignatureSource is implemented by the signature sources telling the custom errors
+// apart from the functions, the error selectors are looked up as functions otherwise.
+type ErrorSignatureSource interface {
+	ErrorSignatures(ctx context.Context, selector string) ([]string, error)
+}
+
+// lookupErrorSignatures returns the signatures of an error selector known to the source.
+func lookupErrorSignatures(ctx context.Context, source SignatureSource, selector string) ([]string, error) {
+	if errSource, ok := source.(ErrorSignatureSource); ok {
+		sigs, err := errSource.ErrorSignatures(ctx, selector)
+		if err != nil || len(sigs) > 0 {
+			return sigs, err
+		}
+	}
+	return source.FunctionSignatures(ctx, selector)
+}
+
+// SignaturePrefetcher is implemented by the signature sources looking up many ids at
+// once, such as remote APIs. The analyzer prefetches the ids still unknown before
+// resolving them one by one.
+type SignaturePrefetcher interface {
+	PrefetchSignatures(ctx context.Context, functions []string, events []string) error
+}
+
+// CodeFetcher fetches the code and the storage of deployed contracts, it is implemented
+// by go-ethereum's ethclient.Client.
+type CodeFetcher interface {
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+}
+
+type AnalyzerConfig struct {
+	Interfaces   []Interface       // known interfaces to resolve signatures from and match against
+	Signatures   []SignatureSource // sources of the signatures unknown to the interfaces, consulted in order
+	Fetcher      CodeFetcher       // required by Analyze only
+	SelectorMode SelectorMode      // defaults to SelectorModePattern
+}
+
+// Analyzer identifies the methods, events and interfaces of contracts.
+type Analyzer struct {
+	config AnalyzerConfig
+}
+
+func NewAnalyzer(config AnalyzerConfig) *Analyzer {
+	if config.SelectorMode == "" {
+		config.SelectorMode = SelectorModePattern
+	}
+	return &Analyzer{config: config}
+}
+
+// Analyze fetches the code of the contract at the address and analyses it, the
+// implementation of EIP-1967 proxies is read from the storage.
+func (a *Analyzer) Analyze(ctx context.Context, addr common.Address) (*Report, error) {
+	if a.config.Fetcher == nil {
+		return nil, errors.New("no code fetcher configured")
+	}
+	code, err := a.config.Fetcher.CodeAt(ctx, addr, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not get contract bytecode: %w", err)
+	}
+	if len(code) == 0 {
+		return nil, fmt.Errorf("no contract code at address %s", addr.Hex())
+	}
+	report, err := a.analyze(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	report.Address = addr.Hex()
+	if report.Proxy.IsProxy {
+		slot, err := a.config.Fetcher.StorageAt(ctx, addr, eip1967ImplementationSlot, nil)
+		if impl := common.BytesToAddress(slot); err == nil && (impl != common.Address{}) {
+			report.Proxy.Implementation = impl.Hex()
+		}
+	}
+	return report, nil
+}
+
+// AnalyzeBytecode analyses the runtime code of a contract.
+func (a *Analyzer) AnalyzeBytecode(code []byte) (*Report, error) {
+	return a.analyze(context.Background(), code)
+}
+
+// AnalyzeInitCode extracts the runtime code from the contract creation code and analyses it.
+func (a *Analyzer) AnalyzeInitCode(initcode []byte) (*Report, error) {
+	creation, err := ParseInitCode(initcode)
+	if err != nil {
+		return nil, err
+	}
+	report, err := a.analyze(context.Background(), creation.Runtime)
+	if err != nil {
+		return nil, err
+	}
+	report.Creation = &CreationReport{
+		RuntimeOffset: creation.RuntimeOffset,
+		RuntimeSize:   len(creation.Runtime),
+	}
+	if len(creation.ConstructorArgs) > 0 {
+		report.Creation.ConstructorArgs = hexutil.Encode(creation.ConstructorArgs)
+	}
+	return report, nil
+}
+
+// functionSelectors extracts the function selectors with the configured method, the
+// confidence tells whether both methods agree on a selector. The dispatcher of EOF code
+// is not emulated, its selectors are found by the patterns only whatever the method.
+func (a *Analyzer) functionSelectors(code []byte) ([]Selector, func(id string) float64, error) {
+	var patterns, emulated []Selector
+	eof := IsEOF(code)
+	if eof {
+		patterns = parseEOFDispatcher(code).Selectors
+	} else {
+		patterns = ParseDispatcher(code).Selectors
+		emulated = EmulateDispatcher(code).Selectors
+	}
+	contains := func(selectors []Selector, id string) bool {
+		return slices.ContainsFunc(selectors, func(sel Selector) bool { return sel.ID == id })
+	}
+	var selectors []Selector
+	switch a.config.SelectorMode {
+	case SelectorModePattern:
+		selectors = patterns
+	case SelectorModeEmulate:
+		selectors = emulated
+		if eof {
+			selectors = patterns
+		}
+	case SelectorModeBoth:
+		selectors = append([]Selector{}, patterns...)
+		for _, sel := range emulated {
+			if !contains(selectors, sel.ID) {
+				selectors = append(selectors, sel)
+			}
+		}
+	default:
+		return nil, nil, fmt.Errorf("invalid selector mode %s", a.config.SelectorMode)
+	}
+	confidence := func(id string) float64 {
+		if contains(patterns, id) && contains(emulated, id) {
+			return 1
+		}
+		return 0.5
+	}
+	return selectors, confidence, nil
+}
+
+// signatureQuery is a selector or a topic to resolve and its signatures.
+type signatureQuery struct {
+	Kind       SignatureKind
+	ID         string
+	Signatures []string
+}
+
+func (q *signatureQuery) lookup(ctx context.Context, source SignatureSource) ([]string, error) {
+	switch q.Kind {
+	case KindEvent:
+		return source.EventSignatures(ctx, q.ID)
+	case KindError:
+		return lookupErrorSignatures(ctx, source, q.ID)
+	}
+	return source.FunctionSignatures(ctx, q.ID)
+}
+
+// resolve completes the signatures of the queries unknown to the interfaces with the
+// signature sources, consulted in turn until one of them knows the id. The ids still
+// unknown are prefetched at once by the sources supporting it. A source failing does not
+// abort the analysis, its ids are left unknown and the failures are returned as warnings.
+func (a *Analyzer) resolve(ctx context.Context, queries []*signatureQuery) []string {
+	warnings := make([]string, 0)
+	for _, source := range a.config.Signatures {
+		if prefetcher, ok := source.(SignaturePrefetcher); ok {
+			var functions, events []string
+			for _, q := range queries {
+				if len(q.Signatures) > 0 {
+					continue
+				}
+				if q.Kind == KindEvent {
+					events = append(events, q.ID)
+				} else {
+					functions = append(functions, q.ID)
+				}
+			}
+			if len(functions) == 0 && len(events) == 0 {
+				break
+			}
+			if err := prefetcher.PrefetchSignatures(ctx, functions, events); err != nil {
+				warnings = append(warnings, fmt.Sprintf("could not look up signatures: %v", err))
+				continue
+			}
+		}
+		for _, q := range queries {
+			if len(q.Signatures) > 0 {
+				continue
+			}
+			found, err := q.lookup(ctx, source)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("could not resolve signature of %s: %v", q.ID, err))
+				continue
+			}
+			for _, sig := range found {
+				if !slices.Contains(q.Signatures, sig) {
+					q.Signatures = append(q.Signatures, sig)
+				}
+			}
+		}
+	}
+	for _, q := range queries {
+		sort.Strings(q.Signatures)
+	}
+	return warnings
+}
+
+func describeFormat(code []byte) (string, error) {
+	if !IsEOF(code) {
+		return "legacy", nil
+	}
+	container, err := ParseEOFContainer(code)
+	if err != nil {
+		return "", fmt.Errorf("could not parse EOF container: %w", err)
+	}
+	return fmt.Sprintf("EOF v%d (%d code sections, %d containers, %d bytes data)",
+		container.Version, len(container.CodeSections), len(container.Containers), container.DataSize), nil
+}
+
+func (a *Analyzer) analyze(ctx context.Context, code []byte) (*Report, error) {
+	report := &Report{}
+	format, err := describeFormat(code)
+	if err != nil {
+		return nil, err
+	}
+	report.Format = format
+	if metadata, err := ParseMetadata(code); err == nil {
+		report.Metadata = NewMetadataReport(metadata)
+	}
+	compiler := Fingerprint(code)
+	report.Compiler = CompilerReport{
+		Name:      string(compiler.Compiler),
+		Version:   compiler.VersionRange(),
+		Optimizer: compiler.Optimizer,
+	}
+	report.Proxy.IsProxy = IsProxy(code)
+
+	selectors, confidence, err := a.functionSelectors(code)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(selectors, func(i, j int) bool { return selectors[i].ID < selectors[j].ID })
+	topics := ParseEventTopics(code)
+	sort.Strings(topics)
+	reverts := ParseErrors(code)
+
+	functionQueries := make([]*signatureQuery, 0, len(selectors))
+	for _, sel := range selectors {
+		functionQueries = append(functionQueries, &signatureQuery{Kind: KindFunction, ID: sel.ID, Signatures: GetMethodSigsByID(sel.ID, a.config.Interfaces)})
+	}
+	eventQueries := make([]*signatureQuery, 0, len(topics))
+	for _, topic := range topics {
+		eventQueries = append(eventQueries, &signatureQuery{Kind: KindEvent, ID: topic, Signatures: GetMethodSigsByID(topic, a.config.Interfaces)})
+	}
+	errorQueries := make([]*signatureQuery, 0, len(reverts.Selectors))
+	for _, id := range reverts.Selectors {
+		errorQueries = append(errorQueries, &signatureQuery{Kind: KindError, ID: id, Signatures: GetErrorSigsByID(id, a.config.Interfaces)})
+	}
+	report.Warnings = a.resolve(ctx, slices.Concat(functionQueries, eventQueries, errorQueries))
+
+	ids := make([]string, 0, len(selectors)+len(topics)+len(reverts.Selectors))
+	report.Selectors = make([]SelectorReport, 0, len(selectors))
+	for i, sel := range selectors {
+		sigs := functionQueries[i].Signatures
+		selReport := SelectorReport{Selector: sel.ID, Signatures: sigs, Confidence: confidence(sel.ID)}
+		if !IsEOF(code) {
+			selReport.StateMutability = InferStateMutability(code, sel.Entry)
+			if len(sigs) == 0 {
+				selReport.Inferred = InferSignature(code, sel.Entry)
+			}
+		}
+		report.Selectors = append(report.Selectors, selReport)
+		ids = append(ids, sel.ID)
+	}
+	report.Events = make([]EventReport, 0, len(topics))
+	for _, q := range eventQueries {
+		report.Events = append(report.Events, EventReport{Topic: q.ID, Signatures: q.Signatures})
+	}
+	ids = append(ids, topics...)
+	report.Reasons = reverts.Reasons
+	report.Errors = make([]ErrorReport, 0, len(reverts.Selectors))
+	for _, q := range errorQueries {
+		report.Errors = append(report.Errors, ErrorReport{Selector: q.ID, Signatures: q.Signatures})
+		ids = append(ids, q.ID)
+	}
+	report.Interfaces = MatchInterfaces(a.config.Interfaces, ids)
+	return report, nil
+}
@@ -0,0 +1,109 @@
+package dasm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type mapSignatures map[string][]string
+
+func (m mapSignatures) FunctionSignatures(ctx context.Context, selector string) ([]string, error) {
+	return m[selector], nil
+}
+
+func (m mapSignatures) EventSignatures(ctx context.Context, topic string) ([]string, error) {
+	return m[topic], nil
+}
+
+type failingSignatures struct{}
+
+func (failingSignatures) FunctionSignatures(ctx context.Context, selector string) ([]string, error) {
+	return nil, errors.New("service unavailable")
+}
+
+func (failingSignatures) EventSignatures(ctx context.Context, topic string) ([]string, error) {
+	return nil, errors.New("service unavailable")
+}
+
+type mapFetcher map[common.Address][]byte
+
+func (m mapFetcher) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return m[account], nil
+}
+
+func (m mapFetcher) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return make([]byte, 32), nil
+}
+
+func TestAnalyzer(t *testing.T) {
+	code := assemble(t, `
+		PUSH0 CALLDATALOAD PUSH1 0xe0 SHR
+		DUP1 PUSH4 0x313ce567 EQ PUSH2 @decimals JUMPI
+		DUP1 PUSH4 0x06fdde03 EQ PUSH2 @name JUMPI
+		PUSH0 DUP1 REVERT
+		decimals: JUMPDEST PUSH4 0xdeadbeef PUSH1 0xe0 SHL PUSH0 MSTORE PUSH1 0x04 PUSH0 REVERT
+		name: JUMPDEST PUSH1 0x04 CALLDATALOAD PUSH20 0xffffffffffffffffffffffffffffffffffffffff AND STOP`)
+	addr := common.HexToAddress("0xdac17f958d2ee523a2206206994597c13d831ec7")
+	analyzer := NewAnalyzer(AnalyzerConfig{
+		Signatures: []SignatureSource{mapSignatures{"313ce567": {"decimals()"}}},
+		Fetcher:    mapFetcher{addr: code},
+	})
+	report, err := analyzer.Analyze(context.Background(), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Address != addr.Hex() || len(report.Selectors) != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if sel := report.Selectors[1]; sel.Selector != "313ce567" || len(sel.Signatures) != 1 || sel.Signatures[0] != "decimals()" || sel.Confidence != 1 {
+		t.Errorf("unexpected selector %+v", sel)
+	}
+	if sel := report.Selectors[0]; len(sel.Signatures) != 0 || sel.Inferred != "f(address)" || sel.StateMutability != MutabilityPayable {
+		t.Errorf("unexpected inferred signature %+v", sel)
+	}
+	if len(report.Errors) != 1 || report.Errors[0].Selector != "deadbeef" {
+		t.Errorf("unexpected errors %+v", report.Errors)
+	}
+	if _, err := analyzer.Analyze(context.Background(), common.Address{}); err == nil {
+		t.Error("expected error for empty code")
+	}
+}
+
+func TestAnalyzerSourceFailure(t *testing.T) {
+	code := assemble(t, `
+		PUSH0 CALLDATALOAD PUSH1 0xe0 SHR
+		DUP1 PUSH4 0x313ce567 EQ PUSH2 @decimals JUMPI
+		DUP1 PUSH4 0x06fdde03 EQ PUSH2 @decimals JUMPI
+		PUSH0 DUP1 REVERT
+		decimals: JUMPDEST STOP`)
+	analyzer := NewAnalyzer(AnalyzerConfig{
+		Signatures: []SignatureSource{failingSignatures{}, mapSignatures{"313ce567": {"decimals()"}}},
+	})
+	report, err := analyzer.AnalyzeBytecode(code)
+	if err != nil {
+		t.Fatalf("source failure aborted the analysis: %v", err)
+	}
+	if len(report.Selectors) != 2 || len(report.Selectors[0].Signatures) != 0 || len(report.Selectors[1].Signatures) != 1 {
+		t.Errorf("unexpected selectors %+v", report.Selectors)
+	}
+	if len(report.Warnings) != 2 || report.Warnings[0] != "could not resolve signature of 06fdde03: service unavailable" {
+		t.Errorf("unexpected warnings %q", report.Warnings)
+	}
+}
+
+func TestAnalyzerEOFConfidence(t *testing.T) {
+	for _, mode := range []SelectorMode{SelectorModePattern, SelectorModeEmulate, SelectorModeBoth} {
+		report, err := NewAnalyzer(AnalyzerConfig{SelectorMode: mode}).AnalyzeBytecode(eofTestContainer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The EOF selectors are only found by the patterns, no second method confirms them.
+		if len(report.Selectors) != 1 || report.Selectors[0].Selector != "06fdde03" || report.Selectors[0].Confidence != 0.5 {
+			t.Errorf("unexpected %s selectors %+v", mode, report.Selectors)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package dasm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+)
+
+const (
+	argsHeadOffset  = 4     // calldata offset of the first argument, after the selector
+	maxInferredArgs = 32    // arguments read further are ignored
+	bodyMaxSteps    = 20000 // instructions executed when analysing a function body
+	addressBits     = 160
+)
+
+// argumentHooks observes how a function body reads the calldata and guesses the types of
+// the arguments from the way their head words are cleaned up or dereferenced.
+type argumentHooks struct {
+	baseHooks
+	types   map[uint64]string // guessed type by head offset, empty if read without clean up
+	minSize uint64            // minimum size of the arguments checked by the decoder
+}
+
+// headOffset returns the head offset of an argument word read from the calldata.
+func headOffset(v symValue) (uint64, bool) {
+	if v.tag != tagCalldata || !v.ref.IsUint64() {
+		return 0, false
+	}
+	off := v.ref.Uint64()
+	if off < argsHeadOffset || (off-argsHeadOffset)%32 != 0 || (off-argsHeadOffset)/32 >= maxInferredArgs {
+		return 0, false
+	}
+	return off, true
+}
+
+// guess records the type of the argument at the head offset, the first guess wins.
+func (h *argumentHooks) guess(off uint64, typ string) {
+	if h.types[off] == "" {
+		h.types[off] = typ
+	}
+}
+
+// maskType returns the type whose values are cleaned up by the AND mask, e.g. address
+// for the 160 low bits or bytes4 for the 4 high bytes.
+func maskType(mask *uint256.Int) (string, bool) {
+	bits := mask.BitLen()
+	low := new(uint256.Int).Sub(new(uint256.Int).Lsh(uint256.NewInt(1), uint(bits)), uint256.NewInt(1))
+	switch {
+	case bits == 0 || bits%8 != 0:
+		return "", false
+	case mask.Eq(low) && bits == addressBits:
+		return "address", true
+	case mask.Eq(low) && bits < 256:
+		return fmt.Sprintf("uint%d", bits), true
+	}
+	// Left aligned masks clean up the fixed size byte arrays.
+	size := 256 - new(uint256.Int).Not(mask).BitLen()
+	high := new(uint256.Int).Lsh(new(uint256.Int).Sub(new(uint256.Int).Lsh(uint256.NewInt(1), uint(size)), uint256.NewInt(1)), uint(256-size))
+	if bits == 256 && size%8 == 0 && size < 256 && mask.Eq(high) {
+		return fmt.Sprintf("bytes%d", size/8), true
+	}
+	return "", false
+}
+
+func (h *argumentHooks) result(st *emuState, in instruction, args []symValue, res symValue) symValue {
+	// operand returns the other operand if one of them matches.
+	operand := func(match func(symValue) bool) (symValue, symValue, bool) {
+		if match(args[0]) {
+			return args[0], args[1], true
+		}
+		if match(args[1]) {
+			return args[1], args[0], true
+		}
+		return symValue{}, symValue{}, false
+	}
+	isHead := func(v symValue) bool { _, ok := headOffset(v); return ok }
+	isTag := func(tag symTag) func(symValue) bool {
+		return func(v symValue) bool { return v.tag == tag }
+	}
+	switch in.op {
+	case vm.CALLDATASIZE:
+		return taggedValue(tagCalldataSize, nil)
+	case vm.CALLDATALOAD:
+		if off, ok := headOffset(res); ok {
+			h.guess(off, "")
+		}
+		if args[0].tag == tagArgOffset {
+			h.guess(args[0].ref.Uint64(), "bytes")
+			return taggedValue(tagArgLength, args[0].ref)
+		}
+	case vm.SUB:
+		if args[0].tag == tagCalldataSize && args[1].isConst() && args[1].konst.Eq(uint256.NewInt(argsHeadOffset)) {
+			return taggedValue(tagArgsSize, nil)
+		}
+	case vm.LT, vm.SLT, vm.GT, vm.SGT:
+		// The decoder reverts if the calldata is too short for the arguments.
+		if _, k, ok := operand(isTag(tagArgsSize)); ok && k.isConst() && k.konst.IsUint64() && k.konst.Uint64() <= maxInferredArgs*32 {
+			h.minSize = max(h.minSize, k.konst.Uint64())
+		}
+	case vm.AND:
+		if word, mask, ok := operand(isHead); ok && mask.isConst() {
+			if typ, ok := maskType(mask.konst); ok {
+				off, _ := headOffset(word)
+				h.guess(off, typ)
+			}
+		}
+	case vm.SIGNEXTEND:
+		if off, ok := headOffset(args[1]); ok && args[0].isConst() && args[0].konst.LtUint64(31) {
+			h.guess(off, fmt.Sprintf("int%d", (args[0].konst.Uint64()+1)*8))
+		}
+	case vm.SHR:
+		// Vyper rejects the values with bits set above the size of the type.
+		if off, ok := headOffset(args[1]); ok && args[0].isConst() && args[0].konst.LtUint64(256) {
+			switch bits := args[0].konst.Uint64(); {
+			case bits == 1:
+				h.guess(off, "bool")
+			case bits == addressBits:
+				h.guess(off, "address")
+			case bits%8 == 0 && bits > 0:
+				h.guess(off, fmt.Sprintf("uint%d", bits))
+			}
+		}
+	case vm.ISZERO:
+		if off, ok := headOffset(args[0]); ok {
+			return taggedValue(tagArgIsZero, uint256.NewInt(off))
+		}
+		if args[0].tag == tagArgIsZero {
+			h.guess(args[0].ref.Uint64(), "bool")
+		}
+	case vm.ADD:
+		// The head of a dynamic argument is an offset relative to the start of the arguments.
+		if word, base, ok := operand(isHead); ok && base.isConst() && base.konst.Eq(uint256.NewInt(argsHeadOffset)) {
+			off, _ := headOffset(word)
+			return taggedValue(tagArgOffset, uint256.NewInt(off))
+		}
+		if ptr, delta, ok := operand(isTag(tagArgOffset)); ok && delta.isConst() {
+			return ptr
+		}
+	case vm.MUL:
+		if length, size, ok := operand(isTag(tagArgLength)); ok && size.isConst() && size.konst.Eq(uint256.NewInt(32)) {
+			h.types[length.ref.Uint64()] = "uint256[]"
+		}
+	case vm.SHL:
+		if args[1].tag == tagArgLength && args[0].isConst() && args[0].konst.Eq(uint256.NewInt(5)) {
+			h.types[args[1].ref.Uint64()] = "uint256[]"
+		}
+	}
+	return res
+}
+
+// InferArguments guesses the argument types of the function whose body starts at the
+// entry, by emulating the body and watching how it reads the calldata: masks for the
+// addresses and the small integers, `ISZERO ISZERO` for booleans, sign extension for the
+// signed integers and offset/length pairs for the dynamic types. The arguments which are
+// read without any clean up are uint256.
+func InferArguments(bytecode []byte, entry uint64) []string {
+	hooks := &argumentHooks{types: make(map[uint64]string)}
+	emu := newEmulator(bytecode, hooks)
+	emu.maxSteps = bodyMaxSteps
+	emu.run(newEmuState(entry))
+
+	count := hooks.minSize / 32
+	for off := range hooks.types {
+		count = max(count, (off-argsHeadOffset)/32+1)
+	}
+	types := make([]string, count)
+	for i := range types {
+		types[i] = "uint256"
+		if typ := hooks.types[argsHeadOffset+uint64(i)*32]; typ != "" {
+			types[i] = typ
+		}
+	}
+	return types
+}
+
+// InferSignature returns a signature like `f(uint256,address)` made of the guessed
+// argument types of the function whose body starts at the entry.
+func InferSignature(bytecode []byte, entry uint64) string {
+	return fmt.Sprintf("f(%s)", strings.Join(InferArguments(bytecode, entry), ","))
+}
@@ -0,0 +1,55 @@
+package dasm
+
+import (
+	"testing"
+)
+
+func TestInferArguments(t *testing.T) {
+	code := assemble(t, `
+		PUSH0 CALLDATALOAD PUSH1 0xe0 SHR
+		DUP1 PUSH4 0x12345678 EQ PUSH2 @body JUMPI
+		PUSH0 DUP1 REVERT
+		body: JUMPDEST
+		PUSH1 0xa0 PUSH1 0x04 CALLDATASIZE SUB SLT PUSH2 @short JUMPI
+		PUSH1 0x04 CALLDATALOAD PUSH20 0xffffffffffffffffffffffffffffffffffffffff AND
+		PUSH1 0x24 CALLDATALOAD ISZERO ISZERO
+		PUSH1 0x44 CALLDATALOAD PUSH1 0x04 ADD DUP1 CALLDATALOAD PUSH1 0x05 SHL
+		PUSH1 0x64 CALLDATALOAD PUSH1 0x00 SIGNEXTEND
+		STOP
+		short: JUMPDEST PUSH0 DUP1 REVERT`)
+	entry := EmulateDispatcher(code).Selectors[0].Entry
+	if sig := InferSignature(code, entry); sig != "f(address,bool,uint256[],int8,uint256)" {
+		t.Errorf("unexpected inferred signature %s", sig)
+	}
+
+	tail := assemble(t, `JUMPDEST PUSH1 0x24 CALLDATALOAD PUSH1 0x04 ADD CALLDATALOAD STOP`)
+	if sig := InferSignature(tail, 0); sig != "f(uint256,bytes)" {
+		t.Errorf("unexpected inferred signature %s", sig)
+	}
+	if sig := InferSignature(assemble(t, "JUMPDEST STOP"), 0); sig != "f()" {
+		t.Errorf("unexpected inferred signature %s", sig)
+	}
+}
+
+func TestInferArgumentsCompiled(t *testing.T) {
+	expected := map[string]map[string]string{
+		"solc-0.8.7-storage.hex": {"2e64cec1": "f()", "6057361d": "f(uint256)"},
+		"solc-0.8.18-token.hex":  {"a9059cbb": "f(address,uint256)"},
+		"solc-0.6.6-ballot.hex": {
+			"0121b93f": "f(uint256)", "013cf08b": "f(uint256)", "2e4176cf": "f()", "5c19a95c": "f(address)",
+			"609ff1bd": "f()", "9e7b8d61": "f(address)", "a3ec138d": "f(address)", "e2ba53f0": "f()",
+		},
+	}
+	for name, sigs := range expected {
+		code := loadFixture(t, name)
+		selectors := ParseDispatcher(code).Selectors
+		if len(selectors) != len(sigs) {
+			t.Fatalf("%s: unexpected selectors %v", name, selectors)
+		}
+		for _, sel := range selectors {
+			if sig := InferSignature(code, sel.Entry); sig != sigs[sel.ID] {
+				t.Errorf("%s: unexpected inferred signature of %s: %s", name, sel.ID, sig)
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package dasm
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// DecodedCall is calldata decoded against one of the signatures of its selector.
+type DecodedCall struct {
+	Signature  string        // signature of the function, e.g. `transfer(address,uint256)`
+	Interfaces []string      // names of the interfaces declaring the function, empty for signature sources
+	Inputs     abi.Arguments // arguments of the function
+	Values     []interface{} // decoded values of the arguments
+}
+
+// unpackMethod decodes the arguments of the method of the interface matching the selector.
+func unpackMethod(intf Interface, selector []byte, data []byte) (*DecodedCall, bool) {
+	for name, method := range intf.Methods {
+		if !slices.Equal(method.ID, selector) {
+			continue
+		}
+		values := make([]interface{}, len(method.Inputs))
+		// A single argument is copied as is, several arguments as a tuple.
+		var dst interface{} = &values
+		if len(values) == 1 {
+			dst = &values[0]
+		}
+		if err := intf.UnpackInput(dst, name, data); err != nil {
+			return nil, false
+		}
+		return &DecodedCall{Signature: method.Sig, Inputs: method.Inputs, Values: values}, true
+	}
+	return nil, false
+}
+
+// DecodeCalldata decodes the input of a transaction against the functions of the
+// interfaces and the signatures of the sources sharing its selector. All the signatures
+// which decode the input are returned, more than one means the selector is ambiguous.
+// A failing source is skipped, its error is only returned if nothing decodes the input.
+func DecodeCalldata(ctx context.Context, data []byte, interfaces []Interface, sources []SignatureSource) ([]*DecodedCall, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("calldata too short: %#x", data)
+	}
+	selector, args := data[:4], data[4:]
+	calls := make([]*DecodedCall, 0)
+	find := func(sig string) *DecodedCall {
+		idx := slices.IndexFunc(calls, func(call *DecodedCall) bool { return call.Signature == sig })
+		if idx < 0 {
+			return nil
+		}
+		return calls[idx]
+	}
+	for _, intf := range interfaces {
+		call, ok := unpackMethod(intf, selector, args)
+		if !ok {
+			continue
+		}
+		if known := find(call.Signature); known != nil {
+			known.Interfaces = append(known.Interfaces, intf.Name)
+			continue
+		}
+		call.Interfaces = []string{intf.Name}
+		calls = append(calls, call)
+	}
+	id := hex.EncodeToString(selector)
+	var sourceErrs []error
+	for _, source := range sources {
+		sigs, err := source.FunctionSignatures(ctx, id)
+		if err != nil {
+			sourceErrs = append(sourceErrs, fmt.Errorf("could not resolve signature of %s: %w", id, err))
+			continue
+		}
+		for _, sig := range sigs {
+			if find(sig) != nil || FourBytesSigOf(sig) != id {
+				continue
+			}
+			_, inputs, err := ParseSignature(sig)
+			if err != nil {
+				continue
+			}
+			values, err := inputs.Unpack(args)
+			if err != nil {
+				continue
+			}
+			calls = append(calls, &DecodedCall{Signature: sig, Inputs: inputs, Values: values})
+		}
+	}
+	if len(calls) == 0 && len(sourceErrs) > 0 {
+		return nil, errors.Join(sourceErrs...)
+	}
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("no known signature decodes the calldata of selector %s", id)
+	}
+	return calls, nil
+}
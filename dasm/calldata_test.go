@@ -0,0 +1,65 @@
+package dasm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDecodeCalldata(t *testing.T) {
+	intf := loadInterface(t, "Token", `[
+		{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}]},
+		{"type":"function","name":"burn","inputs":[{"name":"amount","type":"uint256"}]}
+	]`)
+	to := common.HexToAddress("0xdac17f958d2ee523a2206206994597c13d831ec7")
+	args, err := intf.Methods["transfer"].Inputs.Pack(to, big.NewInt(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := append(common.FromHex(FourBytesSigOf("transfer(address,uint256)")), args...)
+	calls, err := DecodeCalldata(context.Background(), input, []Interface{intf, intf}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 || calls[0].Signature != "transfer(address,uint256)" || len(calls[0].Interfaces) != 2 ||
+		calls[0].Values[0].(common.Address) != to || calls[0].Values[1].(*big.Int).Int64() != 42 {
+		t.Errorf("unexpected decoded calls %+v", calls)
+	}
+
+	// A single argument and an ambiguous selector from a signature source.
+	args, _ = intf.Methods["burn"].Inputs.Pack(big.NewInt(7))
+	burn := FourBytesSigOf("burn(uint256)")
+	input = append(common.FromHex(burn), args...)
+	sources := []SignatureSource{mapSignatures{burn: {"burn(uint256)", "collate_propagate_storage(bytes16)", "mismatch(uint8)"}}}
+	calls, err = DecodeCalldata(context.Background(), input, []Interface{intf}, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || calls[0].Values[0].(*big.Int).Int64() != 7 || calls[1].Signature != "collate_propagate_storage(bytes16)" {
+		t.Errorf("unexpected decoded calls %+v", calls)
+	}
+
+	// Calldata of delegate(address) sent to the Ballot fixture.
+	ballot := loadInterface(t, "Ballot", `[{"type":"function","name":"delegate","inputs":[{"name":"to","type":"address"}],"stateMutability":"nonpayable"}]`)
+	input = common.FromHex("0x5c19a95c000000000000000000000000f7579c3d8a669c89d5ed246a22eb6db8f6fedbf1")
+	calls, err = DecodeCalldata(context.Background(), input, []Interface{ballot}, nil)
+	if err != nil || len(calls) != 1 || calls[0].Values[0].(common.Address) != common.HexToAddress("0xf7579c3d8a669c89d5ed246a22eb6db8f6fedbf1") {
+		t.Errorf("unexpected decoded delegate call %+v: %v", calls, err)
+	}
+
+	// A failing source does not discard the calls decoded by the interfaces.
+	input = append(common.FromHex(burn), args...)
+	calls, err = DecodeCalldata(context.Background(), input, []Interface{intf}, []SignatureSource{failingSignatures{}})
+	if err != nil || len(calls) != 1 || calls[0].Signature != "burn(uint256)" {
+		t.Errorf("unexpected decoded calls with a failing source %+v: %v", calls, err)
+	}
+	if _, err := DecodeCalldata(context.Background(), common.FromHex("0xdeadbeef"), nil, []SignatureSource{failingSignatures{}}); err == nil {
+		t.Error("expected the error of the failing source")
+	}
+
+	if _, err := DecodeCalldata(context.Background(), common.FromHex("0xdeadbeef"), []Interface{intf}, nil); err == nil {
+		t.Error("expected error for unknown selector")
+	}
+}
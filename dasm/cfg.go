@@ -0,0 +1,189 @@
+package dasm
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// EdgeKind describes how control is transferred between two basic blocks.
+type EdgeKind int
+
+const (
+	EdgeFallthrough EdgeKind = iota // execution continues with the next block
+	EdgeJump                        // unconditional JUMP to a static target
+	EdgeBranch                      // taken branch of a JUMPI to a static target
+)
+
+func (k EdgeKind) String() string {
+	switch k {
+	case EdgeFallthrough:
+		return "fallthrough"
+	case EdgeJump:
+		return "jump"
+	case EdgeBranch:
+		return "branch"
+	}
+	return "unknown"
+}
+
+// Edge is a directed control-flow edge between two basic blocks, identified by their start PC.
+type Edge struct {
+	From uint64
+	To   uint64
+	Kind EdgeKind
+}
+
+// BasicBlock is a straight-line sequence of instructions with a single entry point
+// and a single exit point.
+type BasicBlock struct {
+	Start        uint64        // PC of the first instruction
+	End          uint64        // PC of the last instruction
+	Instructions []instruction // instructions of the block in execution order
+	Succs        []uint64      // start PCs of the successor blocks
+	Preds        []uint64      // start PCs of the predecessor blocks
+}
+
+// Last returns the last instruction of the block.
+func (b *BasicBlock) Last() instruction {
+	return b.Instructions[len(b.Instructions)-1]
+}
+
+// IsJumpDest returns true if the block starts with a JUMPDEST and therefore can be jumped to.
+func (b *BasicBlock) IsJumpDest() bool {
+	return b.Instructions[0].op == vm.JUMPDEST
+}
+
+// CFG is the control-flow graph of a legacy EVM bytecode.
+type CFG struct {
+	Blocks       []*BasicBlock // basic blocks ordered by start PC
+	Edges        []Edge        // all resolved edges
+	DynamicJumps []uint64      // PCs of JUMP/JUMPI instructions whose target could not be resolved statically
+
+	blocks map[uint64]*BasicBlock
+}
+
+// Block returns the block starting at the given PC, or nil if there is none.
+func (g *CFG) Block(start uint64) *BasicBlock {
+	return g.blocks[start]
+}
+
+// BlockAt returns the block containing the instruction at the given PC, or nil if there is none.
+func (g *CFG) BlockAt(pc uint64) *BasicBlock {
+	idx := sort.Search(len(g.Blocks), func(i int) bool { return g.Blocks[i].End >= pc })
+	if idx < len(g.Blocks) && g.Blocks[idx].Start <= pc {
+		return g.Blocks[idx]
+	}
+	return nil
+}
+
+// Reachable returns the start PCs of all blocks reachable from the block starting at `from`
+// following the resolved edges only.
+func (g *CFG) Reachable(from uint64) map[uint64]bool {
+	visited := make(map[uint64]bool)
+	if g.blocks[from] == nil {
+		return visited
+	}
+	queue := []uint64{from}
+	visited[from] = true
+	for len(queue) > 0 {
+		block := g.blocks[queue[0]]
+		queue = queue[1:]
+		for _, succ := range block.Succs {
+			if !visited[succ] {
+				visited[succ] = true
+				queue = append(queue, succ)
+			}
+		}
+	}
+	return visited
+}
+
+func (g *CFG) addEdge(from, to *BasicBlock, kind EdgeKind) {
+	g.Edges = append(g.Edges, Edge{From: from.Start, To: to.Start, Kind: kind})
+	from.Succs = append(from.Succs, to.Start)
+	to.Preds = append(to.Preds, from.Start)
+}
+
+// isBlockTerminator returns true if the instruction ends a basic block.
+func isBlockTerminator(op vm.OpCode) bool {
+	switch op {
+	case vm.JUMP, vm.JUMPI, vm.STOP, vm.RETURN, vm.REVERT, vm.INVALID, vm.SELFDESTRUCT:
+		return true
+	}
+	return false
+}
+
+// pushTarget returns the value pushed by the instruction if it is a PUSH, used to resolve static jumps.
+func pushTarget(in instruction) (uint64, bool) {
+	if !in.op.IsPush() {
+		return 0, false
+	}
+	val := new(big.Int).SetBytes(in.arg)
+	if !val.IsUint64() {
+		return 0, false
+	}
+	return val.Uint64(), true
+}
+
+// newCFG builds the control-flow graph from the instructions decoded by the iterator.
+// Decoding errors are ignored, the graph covers the instructions decoded before the error.
+func newCFG(it *instructionIterator) *CFG {
+	g := &CFG{blocks: make(map[uint64]*BasicBlock)}
+	var cur *BasicBlock
+	for it.Next() {
+		in := it.Instruction()
+		if cur != nil && in.op == vm.JUMPDEST {
+			cur = nil
+		}
+		if cur == nil {
+			cur = &BasicBlock{Start: in.pc}
+			g.Blocks = append(g.Blocks, cur)
+			g.blocks[in.pc] = cur
+		}
+		cur.Instructions = append(cur.Instructions, in)
+		cur.End = in.pc
+		if isBlockTerminator(in.op) {
+			cur = nil
+		}
+	}
+
+	for i, block := range g.Blocks {
+		last := block.Last()
+		if last.op == vm.JUMP || last.op == vm.JUMPI {
+			kind := EdgeJump
+			if last.op == vm.JUMPI {
+				kind = EdgeBranch
+			}
+			resolved := false
+			if n := len(block.Instructions); n >= 2 {
+				if target, ok := pushTarget(block.Instructions[n-2]); ok {
+					resolved = true
+					if dest := g.blocks[target]; dest != nil && dest.IsJumpDest() {
+						g.addEdge(block, dest, kind)
+					}
+				}
+			}
+			if !resolved {
+				g.DynamicJumps = append(g.DynamicJumps, last.pc)
+			}
+		}
+		if i+1 < len(g.Blocks) && (last.op == vm.JUMPI || !isBlockTerminator(last.op)) {
+			g.addEdge(block, g.Blocks[i+1], EdgeFallthrough)
+		}
+	}
+	return g
+}
+
+// BuildCFG splits the disassembled bytecode into basic blocks and connects them with
+// the statically resolvable control-flow edges. Jumps whose target is not pushed right
+// before the JUMP/JUMPI instruction are reported in CFG.DynamicJumps.
+func BuildCFG(bytecode []byte) (*CFG, error) {
+	it := NewInstructionIterator(StripMetadata(bytecode))
+	g := newCFG(it)
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
@@ -0,0 +1,57 @@
+package dasm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildCFG(t *testing.T) {
+	bytecode := []byte{
+		0x60, 0x04, // 00: PUSH1 0x04
+		0x36,       // 02: CALLDATASIZE
+		0x10,       // 03: LT
+		0x60, 0x0d, // 04: PUSH1 0x0d
+		0x57,       // 06: JUMPI
+		0x60, 0x00, // 07: PUSH1 0x00
+		0x80, // 09: DUP1
+		0xfd, // 0a: REVERT
+		0x5b, // 0b: JUMPDEST
+		0x56, // 0c: JUMP
+		0x5b, // 0d: JUMPDEST
+		0x00, // 0e: STOP
+	}
+	cfg, err := BuildCFG(bytecode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var starts []uint64
+	for _, block := range cfg.Blocks {
+		starts = append(starts, block.Start)
+	}
+	if want := []uint64{0x00, 0x07, 0x0b, 0x0d}; !reflect.DeepEqual(starts, want) {
+		t.Fatalf("block starts mismatch: have %x, want %x", starts, want)
+	}
+	wantEdges := []Edge{
+		{From: 0x00, To: 0x0d, Kind: EdgeBranch},
+		{From: 0x00, To: 0x07, Kind: EdgeFallthrough},
+	}
+	if !reflect.DeepEqual(cfg.Edges, wantEdges) {
+		t.Fatalf("edges mismatch: have %v, want %v", cfg.Edges, wantEdges)
+	}
+	if want := []uint64{0x0c}; !reflect.DeepEqual(cfg.DynamicJumps, want) {
+		t.Fatalf("dynamic jumps mismatch: have %x, want %x", cfg.DynamicJumps, want)
+	}
+	if block := cfg.BlockAt(0x09); block == nil || block.Start != 0x07 {
+		t.Fatalf("wrong block containing pc 0x09: %v", block)
+	}
+	reachable := cfg.Reachable(0)
+	if len(reachable) != 3 || reachable[0x0b] {
+		t.Fatalf("unexpected reachable blocks: %v", reachable)
+	}
+}
+
+func TestBuildCFGIncomplete(t *testing.T) {
+	if _, err := BuildCFG([]byte{0x60, 0x01, 0x61, 0x01}); err == nil {
+		t.Fatal("expected error for incomplete instruction")
+	}
+}
@@ -1,11 +1,13 @@
 package dasm
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 )
 
 // isSubset check if `sset` is a subset of `set`
@@ -48,3 +50,27 @@ func LoadInterfaces(abiDir string) ([]Interface, error) {
 	}
 	return interfaces, nil
 }
+
+// isText tells whether the data is made of printable ASCII characters and whitespaces only.
+func isText(data []byte) bool {
+	for _, b := range data {
+		if (b < 0x20 || b > 0x7e) && b != '\t' && b != '\n' && b != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+// DecodeBytecode decodes bytecode read from a file, which is either hex encoded text with
+// an optional 0x prefix or the raw binary code. Text which is not valid hex is rejected
+// rather than taken as binary code.
+func DecodeBytecode(data []byte) ([]byte, error) {
+	if !isText(data) {
+		return data, nil
+	}
+	code, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+	if err != nil {
+		return nil, err
+	}
+	return code, nil
+}
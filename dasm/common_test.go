@@ -0,0 +1,26 @@
+package dasm
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeBytecode(t *testing.T) {
+	tests := []struct {
+		input []byte
+		want  []byte
+		err   bool
+	}{
+		{[]byte("0x6080604052\n"), []byte{0x60, 0x80, 0x60, 0x40, 0x52}, false},
+		{[]byte("6000"), []byte{0x60, 0x00}, false},
+		{[]byte{0x60, 0x80, 0x60, 0x40, 0x52}, []byte{0x60, 0x80, 0x60, 0x40, 0x52}, false},
+		{[]byte("0xZZ6000"), nil, true},
+		{[]byte("608060405"), nil, true},
+	}
+	for _, test := range tests {
+		code, err := DecodeBytecode(test.input)
+		if (err != nil) != test.err || !bytes.Equal(code, test.want) {
+			t.Errorf("DecodeBytecode(%q) = %x, %v", test.input, code, err)
+		}
+	}
+}
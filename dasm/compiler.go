@@ -0,0 +1,65 @@
+package dasm
+
+import (
+	"bytes"
+)
+
+// Compiler is the family of the compiler that produced a bytecode.
+type Compiler string
+
+const (
+	CompilerUnknown Compiler = "unknown"
+	CompilerSolc    Compiler = "solc"
+	CompilerVyper   Compiler = "vyper"
+	CompilerHuff    Compiler = "huff"
+)
+
+var (
+	// solc initializes the free memory pointer first thing in the runtime code,
+	// `PUSH1 0x80 PUSH1 0x40 MSTORE` since 0.4.22 and `PUSH1 0x60 PUSH1 0x40 MSTORE` before.
+	solcPreludes = [][]byte{
+		{0x60, 0x80, 0x60, 0x40, 0x52},
+		{0x60, 0x60, 0x60, 0x40, 0x52},
+	}
+	// vyperMetadataKey is the CBOR encoded "vyper" text key of the vyper metadata.
+	vyperMetadataKey = []byte{0x65, 'v', 'y', 'p', 'e', 'r'}
+)
+
+// hasVyperSelectors returns true if the bytecode compares the selector the way vyper does.
+// Only the patterns registered for vyper tell it, not the ones applying to every family.
+func hasVyperSelectors(bytecode []byte) bool {
+	patterns := make([]compiledPattern, 0)
+	for _, p := range patternsFor(CompilerVyper) {
+		if p.Compiler == CompilerVyper {
+			patterns = append(patterns, p)
+		}
+	}
+	it := NewInstructionIterator(bytecode)
+	for it.Next() {
+		for _, p := range patterns {
+			if matchPattern(it.Instructions(len(p.match)), p.match) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DetectCompiler returns the best guess of the compiler family that produced the bytecode.
+func DetectCompiler(bytecode []byte) Compiler {
+	if m, err := ParseMetadata(bytecode); err == nil && m.Compiler != CompilerUnknown {
+		return m.Compiler
+	}
+	if tail := bytecode[len(bytecode)-minInt(len(bytecode), 32):]; bytes.Contains(tail, vyperMetadataKey) {
+		return CompilerVyper
+	}
+	for _, prelude := range solcPreludes {
+		if bytes.HasPrefix(bytecode, prelude) {
+			return CompilerSolc
+		}
+	}
+	if hasVyperSelectors(bytecode) {
+		return CompilerVyper
+	}
+	return CompilerUnknown
+}
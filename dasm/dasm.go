@@ -39,6 +39,7 @@ type InstructionIterator interface {
 type instruction struct {
 	op  vm.OpCode
 	arg []byte
+	pc  uint64
 }
 
 func (i *instruction) Equal(other instruction) bool {
@@ -60,12 +61,16 @@ func (ins instruction) Arg() []byte {
 	return ins.arg
 }
 
+func (ins instruction) PC() uint64 {
+	return ins.pc
+}
+
 func newInstruction(op vm.OpCode) instruction {
 	return instruction{op: op}
 }
 
 func newInstructionWithArg(op vm.OpCode, arg []byte) instruction {
-	return instruction{op, arg}
+	return instruction{op: op, arg: arg}
 }
 
 // Iterator for disassembled EVM instructions
@@ -112,6 +117,10 @@ func (it *instructionIterator) Next() bool {
 		if it.op == vm.RJUMPV {
 			// RJUMPV is unique as it has a variable sized operand. The total size is
 			// determined by the count byte which immediately follows RJUMPV.
+			if uint64(len(it.code)) <= it.pc+1 {
+				it.error = fmt.Errorf("incomplete instruction at %v", it.pc)
+				return false
+			}
 			maxIndex := int(it.code[it.pc+1])
 			a = (maxIndex+1)*2 + 1
 		} else {
@@ -128,7 +137,7 @@ func (it *instructionIterator) Next() bool {
 	} else {
 		it.arg = nil
 	}
-	it.ins = append(it.ins, instruction{it.op, it.arg})
+	it.ins = append(it.ins, instruction{it.op, it.arg, it.pc})
 	return true
 }
 
@@ -169,7 +178,7 @@ func (it *instructionIterator) Instructions(n int) []instruction {
 }
 
 func (it *instructionIterator) Instruction() instruction {
-	return instruction{it.op, it.arg}
+	return instruction{it.op, it.arg, it.pc}
 }
 
 // NewInstructionIterator creates a new instruction iterator.
@@ -186,28 +195,46 @@ func NewEOFInstructionIterator(code []byte) *instructionIterator {
 	return it
 }
 
-// PrintDisassembled pretty-print all disassembled EVM instructions to stdout.
+// PrintDisassembled pretty-print all disassembled EVM instructions to stdout, the data
+// regions and the metadata are listed as is like Disassemble does.
 func PrintDisassembled(code string) error {
 	script, err := hex.DecodeString(code)
 	if err != nil {
 		return err
 	}
-	it := NewInstructionIterator(script)
-	for it.Next() {
-		if it.Arg() != nil && 0 < len(it.Arg()) {
-			fmt.Printf("%05x: %v %#x\n", it.PC(), it.Op(), it.Arg())
-		} else {
-			fmt.Printf("%05x: %v\n", it.PC(), it.Op())
-		}
+	instrs, err := Disassemble(script)
+	if err != nil {
+		return err
 	}
-	return it.Error()
+	for _, instr := range instrs {
+		fmt.Print(instr)
+	}
+	return nil
 }
 
+// dataLineSize is the number of data bytes listed per line.
+const dataLineSize = 32
+
 // Disassemble returns all disassembled EVM instructions in human-readable format.
+// EOF containers are split in their sections and disassembled section by section.
+// The unreachable trailing data and the metadata of legacy code are not disassembled
+// but listed as is.
 func Disassemble(script []byte) ([]string, error) {
+	if IsEOF(script) {
+		container, err := ParseEOFContainer(script)
+		if err != nil {
+			return nil, err
+		}
+		return container.Disassemble()
+	}
 	instrs := make([]string, 0)
 
-	it := NewInstructionIterator(script)
+	metadata, _ := ParseMetadata(script)
+	if metadata != nil {
+		script = script[:metadata.Offset]
+	}
+	code, data := SplitCode(script)
+	it := NewInstructionIterator(code)
 	for it.Next() {
 		if it.Arg() != nil && 0 < len(it.Arg()) {
 			instrs = append(instrs, fmt.Sprintf("%05x: %v %#x\n", it.PC(), it.Op(), it.Arg()))
@@ -218,5 +245,13 @@ func Disassemble(script []byte) ([]string, error) {
 	if err := it.Error(); err != nil {
 		return nil, err
 	}
+	for offset := 0; offset < len(data); offset += dataLineSize {
+		chunk := data[offset:minInt(offset+dataLineSize, len(data))]
+		instrs = append(instrs, fmt.Sprintf("%05x: .data %#x\n", len(code)+offset, chunk))
+	}
+	if metadata != nil {
+		instrs = append(instrs, fmt.Sprintf("; metadata: %s\n", metadata))
+		instrs = append(instrs, fmt.Sprintf("%05x: .metadata %#x\n", metadata.Offset, metadata.Raw))
+	}
 	return instrs, nil
 }
@@ -0,0 +1,292 @@
+package dasm
+
+import (
+	"encoding/hex"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Selector is a function selector found in the dispatcher of a contract.
+type Selector struct {
+	ID    string // 4-bytes function selector in hex
+	Entry uint64 // PC of the function body the dispatcher jumps to
+}
+
+// Dispatcher holds the result of walking the function dispatcher of a contract.
+type Dispatcher struct {
+	Selectors []Selector // selectors the calldata is compared against for equality
+	Pivots    []string   // pivot values used to split a binary-search dispatcher, not selectors
+	Compiler  Compiler   // compiler family the dispatcher shape was matched for
+	Patterns  []string   // names of the dispatcher patterns found in the bytecode
+}
+
+// IDs returns the hex encoded selectors of the dispatcher.
+func (d *Dispatcher) IDs() []string {
+	ids := make([]string, len(d.Selectors))
+	for i, sel := range d.Selectors {
+		ids[i] = sel.ID
+	}
+	return ids
+}
+
+// dispatcherWalker walks the dispatcher tree over the control-flow graph.
+type dispatcherWalker struct {
+	cfg      *CFG
+	compiler Compiler
+	patterns []compiledPattern
+	matched  map[string]bool
+	visited  map[uint64]bool
+	seen     map[string]bool
+	pivots   map[string]bool
+	dispatch *Dispatcher
+}
+
+// selectorOf returns the pushed value of the instruction as a 4-bytes hex selector.
+func selectorOf(in instruction) string {
+	buf := make([]byte, 4)
+	copy(buf[4-minInt(len(in.arg), 4):], in.arg)
+	return hex.EncodeToString(buf)
+}
+
+// blockTail returns the last n instructions of the block, or nil if the block is shorter.
+func blockTail(block *BasicBlock, n int) []instruction {
+	if len(block.Instructions) < n {
+		return nil
+	}
+	return block.Instructions[len(block.Instructions)-n:]
+}
+
+// loadsSelector returns true if the block reads the calldata and extracts the selector
+// out of it, which is how the root of a dispatcher tree starts.
+func loadsSelector(block *BasicBlock) bool {
+	hasLoad := false
+	for _, in := range block.Instructions {
+		switch in.op {
+		case vm.CALLDATALOAD:
+			hasLoad = true
+		case vm.SHR, vm.DIV:
+			if hasLoad {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dispatcherNode is a selector comparison or a pivot split ending a block of the dispatcher.
+type dispatcherNode struct {
+	pattern string       // name of the matched dispatcher pattern
+	kind    DispatchKind // how the JUMPI ending the block routes the execution
+	value   string       // compared selector or pivot value
+	target  uint64       // jump target of the JUMPI
+	wide    bool         // true if the value was pushed as a full width PUSH3/PUSH4
+}
+
+// matchNode returns the dispatcher node the block ends with, if any.
+func (w *dispatcherWalker) matchNode(block *BasicBlock) (dispatcherNode, bool) {
+	for _, p := range w.patterns {
+		ins := blockTail(block, len(p.match))
+		if ins == nil || !matchPattern(ins, p.match) {
+			continue
+		}
+		// Unless the pattern starts the block, its operand is computed right before it.
+		if prev := len(block.Instructions) - len(ins) - 1; len(p.After) > 0 && prev >= 0 &&
+			block.Instructions[prev].op != vm.JUMPDEST && !opAnyOf(p.After...)(block.Instructions[prev]) {
+			continue
+		}
+		node := dispatcherNode{pattern: p.Name, kind: p.Kind, value: "00000000"}
+		node.target, _ = pushTarget(ins[p.Target])
+		if p.Value >= 0 {
+			node.value = selectorOf(ins[p.Value])
+			node.wide = opAnyOf(vm.PUSH3, vm.PUSH4)(ins[p.Value])
+		}
+		return node, true
+	}
+	return dispatcherNode{}, false
+}
+
+func (w *dispatcherWalker) addSelector(id string, entry uint64) {
+	if !w.seen[id] {
+		w.seen[id] = true
+		w.dispatch.Selectors = append(w.dispatch.Selectors, Selector{ID: id, Entry: entry})
+	}
+}
+
+func (w *dispatcherWalker) walk(root *BasicBlock) {
+	queue := []*BasicBlock{root}
+	for len(queue) > 0 {
+		block := queue[0]
+		queue = queue[1:]
+		if w.visited[block.Start] {
+			continue
+		}
+		node, ok := w.matchNode(block)
+		if !ok {
+			continue
+		}
+		w.visited[block.Start] = true
+
+		if !w.matched[node.pattern] {
+			w.matched[node.pattern] = true
+			w.dispatch.Patterns = append(w.dispatch.Patterns, node.pattern)
+		}
+		next := block.End + 1 // the next comparison of the chain
+		switch node.kind {
+		case DispatchPivot:
+			// A pivot splits the selectors in two halves, both of them continue the tree.
+			if !w.pivots[node.value] {
+				w.pivots[node.value] = true
+				w.dispatch.Pivots = append(w.dispatch.Pivots, node.value)
+			}
+			if dest := w.cfg.Block(node.target); dest != nil {
+				queue = append(queue, dest)
+			}
+		case DispatchSkip:
+			w.addSelector(node.value, block.End+1)
+			next = node.target
+		default:
+			w.addSelector(node.value, node.target)
+		}
+		if dest := w.cfg.Block(next); dest != nil {
+			queue = append(queue, dest)
+		}
+	}
+}
+
+// isJumpDest returns true if the pc is the start of a block beginning with a JUMPDEST.
+func (w *dispatcherWalker) isJumpDest(pc uint64) bool {
+	block := w.cfg.Block(pc)
+	return block != nil && block.IsJumpDest()
+}
+
+// parseVyperJumpTable parses the bucket table vyper >= 0.3.10 emits for large dispatchers.
+// The bucket of a selector is `selector % n`, the table is read from the code with CODECOPY
+// and is either sparse, a list of 2-bytes jump destinations to a linear dispatcher per
+// bucket, or dense, a list of 5-bytes bucket headers pointing to the function infos
+// (selector, entry label and metadata) of the bucket.
+func (w *dispatcherWalker) parseVyperJumpTable(block *BasicBlock, code []byte) {
+	var (
+		buckets, table uint64
+		hasCopy        bool
+	)
+	for i, in := range block.Instructions {
+		switch in.op {
+		case vm.MOD:
+			for j := i - 1; j >= 0 && buckets == 0; j-- {
+				buckets, _ = pushTarget(block.Instructions[j])
+			}
+		case vm.ADD:
+			if buckets != 0 && table == 0 {
+				table, _ = pushTarget(block.Instructions[i-1])
+			}
+		case vm.CODECOPY:
+			hasCopy = true
+		}
+	}
+	if !hasCopy || buckets == 0 || buckets > 0xffff || table == 0 {
+		return
+	}
+	readUint16 := func(pos uint64) (uint64, bool) {
+		if pos+2 > uint64(len(code)) {
+			return 0, false
+		}
+		return uint64(code[pos])<<8 | uint64(code[pos+1]), true
+	}
+
+	// Sparse table, every entry is the jump destination of a bucket.
+	roots := make([]uint64, 0, buckets)
+	for i := uint64(0); i < buckets; i++ {
+		dest, ok := readUint16(table + 2*i)
+		if !ok || !w.isJumpDest(dest) {
+			roots = nil
+			break
+		}
+		roots = append(roots, dest)
+	}
+	if roots != nil {
+		w.dispatch.Patterns = append(w.dispatch.Patterns, "vyper-sparse-table")
+		for _, root := range roots {
+			w.walk(w.cfg.Block(root))
+		}
+		return
+	}
+
+	// Dense table, the function infos of a bucket are laid out contiguously so the
+	// size of a function info is the distance between two buckets over the bucket size.
+	type bucket struct{ location, size uint64 }
+	list := make([]bucket, 0, buckets)
+	for i := uint64(0); i < buckets; i++ {
+		header := table + 5*i
+		location, ok := readUint16(header + 2)
+		if !ok || header+4 >= uint64(len(code)) || code[header+4] == 0 {
+			return
+		}
+		list = append(list, bucket{location, uint64(code[header+4])})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].location < list[j].location })
+	widths := []uint64{6, 7, 8, 9, 10}
+	if len(list) > 1 {
+		widths = []uint64{(list[1].location - list[0].location) / list[0].size}
+	}
+	for _, width := range widths {
+		selectors := make([]Selector, 0)
+		for _, b := range list {
+			for i := uint64(0); i < b.size; i++ {
+				info := b.location + i*width
+				label, ok := readUint16(info + 4)
+				if !ok || !w.isJumpDest(label) {
+					selectors = nil
+					break
+				}
+				selectors = append(selectors, Selector{ID: hex.EncodeToString(code[info : info+4]), Entry: label})
+			}
+			if selectors == nil {
+				break
+			}
+		}
+		if selectors != nil {
+			w.dispatch.Patterns = append(w.dispatch.Patterns, "vyper-dense-table")
+			for _, sel := range selectors {
+				w.addSelector(sel.ID, sel.Entry)
+			}
+			return
+		}
+	}
+}
+
+// ParseDispatcher walks the function dispatcher of the bytecode. Dispatcher trees are
+// rooted at the block extracting the selector from the calldata, comparisons are followed
+// through their fall through block and binary-search pivots through both branches.
+// The block shapes are matched against the registered dispatcher patterns of the detected
+// compiler family, the bucket tables of vyper >= 0.3.10 are parsed as well.
+func ParseDispatcher(bytecode []byte) *Dispatcher {
+	w := &dispatcherWalker{
+		cfg:      newCFG(NewInstructionIterator(StripMetadata(bytecode))),
+		compiler: DetectCompiler(bytecode),
+		matched:  make(map[string]bool),
+		visited:  make(map[uint64]bool),
+		seen:     make(map[string]bool),
+		pivots:   make(map[string]bool),
+		dispatch: &Dispatcher{},
+	}
+	w.patterns = patternsFor(w.compiler)
+	w.dispatch.Compiler = w.compiler
+	for _, block := range w.cfg.Blocks {
+		if loadsSelector(block) {
+			w.walk(block)
+			if w.compiler != CompilerSolc {
+				w.parseVyperJumpTable(block, bytecode)
+			}
+		}
+	}
+	// Dispatcher chains which are not connected to a recognised root, e.g. when the
+	// selector is loaded in a preceding block, are picked up by their full width selectors.
+	// Narrow pushes are only trusted inside a chain as they are common outside dispatchers.
+	for _, block := range w.cfg.Blocks {
+		if node, ok := w.matchNode(block); ok && node.wide {
+			w.walk(block)
+		}
+	}
+	return w.dispatch
+}
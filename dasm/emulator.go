@@ -0,0 +1,677 @@
+package dasm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+)
+
+// symTag tells where a non constant abstract value comes from.
+type symTag int
+
+const (
+	tagUnknown       symTag = iota
+	tagCalldata             // CALLDATALOAD at the constant offset `ref`
+	tagSelector             // function selector extracted from the calldata
+	tagSelectorEq           // selector == ref
+	tagSelectorNeq          // selector != ref
+	tagSelectorCmp          // selector ordered against ref with GT/LT, a dispatcher pivot
+	tagCalldataSize         // CALLDATASIZE
+	tagArgsSize             // size of the calldata following the selector
+	tagArgIsZero            // ISZERO of the argument at the head offset ref
+	tagArgOffset            // calldata pointer derived from the dynamic argument at the head offset ref
+	tagArgLength            // length of the dynamic argument at the head offset ref
+	tagCallValue            // CALLVALUE
+	tagCallValueZero        // ISZERO of CALLVALUE
+)
+
+// symValue is an abstract stack or memory value of the emulator. It is either a known
+// constant or a tagged value whose origin the analysis is interested in.
+type symValue struct {
+	konst *uint256.Int // value of a constant, nil if the value is not known
+	tag   symTag       // origin of a non constant value
+	ref   *uint256.Int // constant the tagged value relates to, e.g. the compared selector
+}
+
+var unknownValue = symValue{}
+
+func constValue(val *uint256.Int) symValue {
+	return symValue{konst: val}
+}
+
+func taggedValue(tag symTag, ref *uint256.Int) symValue {
+	return symValue{tag: tag, ref: ref}
+}
+
+func (v symValue) isConst() bool {
+	return v.konst != nil
+}
+
+func (v symValue) String() string {
+	if v.konst != nil {
+		return v.konst.Hex()
+	}
+	if v.ref != nil {
+		return fmt.Sprintf("#%d(%s)", v.tag, v.ref.Hex())
+	}
+	return fmt.Sprintf("#%d", v.tag)
+}
+
+// emuState is the state of a single execution path of the emulator.
+type emuState struct {
+	pc    uint64
+	stack []symValue
+	bytes map[uint64]byte     // memory bytes holding known constants
+	words map[uint64]symValue // tagged words stored in memory at their exact offset
+}
+
+func newEmuState(pc uint64) *emuState {
+	return &emuState{
+		pc:    pc,
+		bytes: make(map[uint64]byte),
+		words: make(map[uint64]symValue),
+	}
+}
+
+func (s *emuState) clone() *emuState {
+	st := newEmuState(s.pc)
+	st.stack = append(make([]symValue, 0, len(s.stack)), s.stack...)
+	for k, v := range s.bytes {
+		st.bytes[k] = v
+	}
+	for k, v := range s.words {
+		st.words[k] = v
+	}
+	return st
+}
+
+// pop removes the top of the stack, values below the bottom of the stack are unknown.
+func (s *emuState) pop() symValue {
+	if len(s.stack) == 0 {
+		return unknownValue
+	}
+	v := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+	return v
+}
+
+func (s *emuState) push(v symValue) {
+	s.stack = append(s.stack, v)
+}
+
+// peek returns the n-th value from the top of the stack, starting at 0.
+func (s *emuState) peek(n int) symValue {
+	if n >= len(s.stack) {
+		return unknownValue
+	}
+	return s.stack[len(s.stack)-1-n]
+}
+
+// swap swaps the top of the stack with the n-th value below it.
+func (s *emuState) swap(n int) {
+	for len(s.stack) <= n {
+		s.stack = append([]symValue{unknownValue}, s.stack...)
+	}
+	top := len(s.stack) - 1
+	s.stack[top], s.stack[top-n] = s.stack[top-n], s.stack[top]
+}
+
+// clearMemory forgets the whole memory, used when writing at an unknown offset.
+func (s *emuState) clearMemory() {
+	s.bytes = make(map[uint64]byte)
+	s.words = make(map[uint64]symValue)
+}
+
+// mstore writes `size` bytes of the value at the offset, size is 32 for MSTORE and 1 for MSTORE8.
+func (s *emuState) mstore(offset uint64, val symValue, size uint64) {
+	for off := offset - minUint64(offset, 31); off < offset+size; off++ {
+		delete(s.words, off)
+	}
+	if !val.isConst() {
+		for off := offset; off < offset+size; off++ {
+			delete(s.bytes, off)
+		}
+		if size == 32 && val.tag != tagUnknown {
+			s.words[offset] = val
+		}
+		return
+	}
+	buf := val.konst.Bytes32()
+	for i := uint64(0); i < size; i++ {
+		s.bytes[offset+i] = buf[32-size+i]
+	}
+}
+
+// mload reads the word at the offset.
+func (s *emuState) mload(offset uint64) symValue {
+	if val, ok := s.words[offset]; ok {
+		return val
+	}
+	if data, ok := s.memory(offset, 32); ok {
+		return constValue(new(uint256.Int).SetBytes(data))
+	}
+	return unknownValue
+}
+
+// memory returns the memory range if all of its bytes are known.
+func (s *emuState) memory(offset, size uint64) ([]byte, bool) {
+	data := make([]byte, size)
+	for i := range data {
+		b, ok := s.bytes[offset+uint64(i)]
+		if !ok {
+			return nil, false
+		}
+		data[i] = b
+	}
+	return data, true
+}
+
+// fingerprint identifies the stack contents which are relevant to the control flow,
+// e.g. the return addresses of internal function calls.
+func (s *emuState) fingerprint() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%x:%d", s.pc, len(s.stack))
+	for _, v := range s.stack {
+		sb.WriteString("|")
+		sb.WriteString(v.String())
+	}
+	return sb.String()
+}
+
+func minUint64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// emuHooks lets an analysis observe and refine the abstract execution.
+type emuHooks interface {
+	// step is called before an instruction is executed with its operands, top of the stack first.
+	step(st *emuState, in instruction, args []symValue)
+	// result may refine the result an instruction computed from its operands.
+	result(st *emuState, in instruction, args []symValue, res symValue) symValue
+	// jumpi tells whether the taken branch and the fall through of a JUMPI should be explored.
+	jumpi(st *emuState, in instruction, dest, cond symValue) (taken bool, fall bool)
+}
+
+// baseHooks is a no-op implementation of emuHooks to be embedded by analyses.
+type baseHooks struct{}
+
+func (baseHooks) step(st *emuState, in instruction, args []symValue) {}
+
+func (baseHooks) result(st *emuState, in instruction, args []symValue, res symValue) symValue {
+	return res
+}
+
+func (baseHooks) jumpi(st *emuState, in instruction, dest, cond symValue) (bool, bool) {
+	return true, true
+}
+
+const (
+	defaultMaxSteps  = 200000 // instructions executed over all paths of a run
+	defaultMaxVisits = 32     // times a jump destination is entered with a different stack
+)
+
+// emulator is a lightweight abstract interpreter of legacy EVM bytecode. It tracks
+// constants and tagged values on the stack and in memory and explores every branch
+// whose outcome is not known, within a budget of executed instructions.
+type emulator struct {
+	code      []byte
+	ins       []instruction
+	index     map[uint64]int // instruction index by pc
+	hooks     emuHooks
+	maxSteps  int
+	maxVisits int
+	steps     int
+}
+
+func newEmulator(code []byte, hooks emuHooks) *emulator {
+	e := &emulator{
+		code:      code,
+		index:     make(map[uint64]int),
+		hooks:     hooks,
+		maxSteps:  defaultMaxSteps,
+		maxVisits: defaultMaxVisits,
+	}
+	it := NewInstructionIterator(StripMetadata(code))
+	for it.Next() {
+		e.index[it.PC()] = len(e.ins)
+		e.ins = append(e.ins, it.Instruction())
+	}
+	return e
+}
+
+func (e *emulator) isJumpDest(dest symValue) (uint64, bool) {
+	if !dest.isConst() || !dest.konst.IsUint64() {
+		return 0, false
+	}
+	idx, ok := e.index[dest.konst.Uint64()]
+	return dest.konst.Uint64(), ok && e.ins[idx].op == vm.JUMPDEST
+}
+
+// run explores all paths starting from the given states.
+func (e *emulator) run(states ...*emuState) {
+	seen := make(map[string]bool)
+	visits := make(map[uint64]int)
+	queue := append([]*emuState{}, states...)
+	for len(queue) > 0 {
+		st := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		for {
+			idx, ok := e.index[st.pc]
+			if !ok || e.steps >= e.maxSteps {
+				break
+			}
+			in := e.ins[idx]
+			if in.op == vm.JUMPDEST {
+				key := st.fingerprint()
+				if seen[key] || visits[st.pc] >= e.maxVisits {
+					break
+				}
+				seen[key] = true
+				visits[st.pc]++
+			}
+			e.steps++
+			fork, cont := e.step(st, in)
+			if fork != nil {
+				queue = append(queue, fork)
+			}
+			if !cont {
+				break
+			}
+		}
+	}
+}
+
+// step executes a single instruction, it returns the state of the taken branch of a
+// JUMPI if it should be explored and whether the current path continues.
+func (e *emulator) step(st *emuState, in instruction) (*emuState, bool) {
+	op := in.op
+	next := in.pc + 1 + uint64(len(in.arg))
+	switch {
+	case op >= vm.DUP1 && op <= vm.DUP16:
+		st.push(st.peek(int(op - vm.DUP1)))
+		st.pc = next
+		return nil, true
+	case op >= vm.SWAP1 && op <= vm.SWAP16:
+		st.swap(int(op-vm.SWAP1) + 1)
+		st.pc = next
+		return nil, true
+	}
+
+	pops, pushes, ok := stackEffect(op)
+	if !ok {
+		// Undefined opcodes halt the execution like INVALID.
+		e.hooks.step(st, in, nil)
+		return nil, false
+	}
+	args := make([]symValue, pops)
+	for i := range args {
+		args[i] = st.pop()
+	}
+	e.hooks.step(st, in, args)
+
+	switch op {
+	case vm.STOP, vm.RETURN, vm.REVERT, vm.INVALID, vm.SELFDESTRUCT:
+		return nil, false
+	case vm.JUMP:
+		dest, ok := e.isJumpDest(args[0])
+		if !ok {
+			return nil, false
+		}
+		st.pc = dest
+		return nil, true
+	case vm.JUMPI:
+		taken, fall := e.hooks.jumpi(st, in, args[0], args[1])
+		if args[1].isConst() {
+			taken = taken && !args[1].konst.IsZero()
+			fall = fall && args[1].konst.IsZero()
+		}
+		var fork *emuState
+		if dest, ok := e.isJumpDest(args[0]); ok && taken {
+			fork = st.clone()
+			fork.pc = dest
+		}
+		st.pc = next
+		return fork, fall
+	case vm.MSTORE, vm.MSTORE8:
+		size := uint64(32)
+		if op == vm.MSTORE8 {
+			size = 1
+		}
+		if args[0].isConst() && args[0].konst.IsUint64() {
+			st.mstore(args[0].konst.Uint64(), args[1], size)
+		} else {
+			st.clearMemory()
+		}
+	case vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY, vm.EXTCODECOPY, vm.MCOPY:
+		e.copyToMemory(st, in, args)
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		// The return data is written to memory.
+		retOffset, retSize := args[len(args)-2], args[len(args)-1]
+		e.forgetMemory(st, retOffset, retSize)
+	}
+	if pushes > 0 {
+		res := e.hooks.result(st, in, args, e.eval(st, in, args))
+		st.push(res)
+	}
+	st.pc = next
+	return nil, true
+}
+
+// forgetMemory forgets the memory range written with unknown contents.
+func (e *emulator) forgetMemory(st *emuState, offset, size symValue) {
+	if !offset.isConst() || !size.isConst() || !offset.konst.IsUint64() || !size.konst.IsUint64() || size.konst.Uint64() > 0x10000 {
+		st.clearMemory()
+		return
+	}
+	off, n := offset.konst.Uint64(), size.konst.Uint64()
+	for i := off - minUint64(off, 31); i < off+n; i++ {
+		delete(st.words, i)
+	}
+	for i := off; i < off+n; i++ {
+		delete(st.bytes, i)
+	}
+}
+
+// copyToMemory emulates the *COPY instructions, CODECOPY from the code itself is known.
+func (e *emulator) copyToMemory(st *emuState, in instruction, args []symValue) {
+	var dst, src, size symValue
+	switch in.op {
+	case vm.EXTCODECOPY:
+		dst, size = args[1], args[3]
+	default:
+		dst, src, size = args[0], args[1], args[2]
+	}
+	e.forgetMemory(st, dst, size)
+	if in.op != vm.CODECOPY || !dst.isConst() || !src.isConst() || !size.isConst() ||
+		!dst.konst.IsUint64() || !src.konst.IsUint64() || !size.konst.IsUint64() || size.konst.Uint64() > 0x10000 {
+		return
+	}
+	off, pos, n := dst.konst.Uint64(), src.konst.Uint64(), size.konst.Uint64()
+	for i := uint64(0); i < n; i++ {
+		var b byte // bytes out of the code are zero
+		if pos+i < uint64(len(e.code)) {
+			b = e.code[pos+i]
+		}
+		st.bytes[off+i] = b
+	}
+}
+
+// eval computes the result of an instruction with a single output.
+func (e *emulator) eval(st *emuState, in instruction, args []symValue) symValue {
+	switch in.op {
+	case vm.PUSH0:
+		return constValue(new(uint256.Int))
+	case vm.PC:
+		return constValue(uint256.NewInt(in.pc))
+	case vm.CODESIZE:
+		return constValue(uint256.NewInt(uint64(len(e.code))))
+	case vm.MLOAD:
+		if args[0].isConst() && args[0].konst.IsUint64() {
+			return st.mload(args[0].konst.Uint64())
+		}
+		return unknownValue
+	case vm.CALLDATALOAD:
+		if args[0].isConst() {
+			return taggedValue(tagCalldata, args[0].konst)
+		}
+		return unknownValue
+	}
+	if in.op.IsPush() {
+		return constValue(new(uint256.Int).SetBytes(in.arg))
+	}
+	for _, arg := range args {
+		if !arg.isConst() {
+			return unknownValue
+		}
+	}
+	if res := evalConst(in.op, args); res != nil {
+		return constValue(res)
+	}
+	return unknownValue
+}
+
+// evalConst folds an instruction over constant operands, it returns nil if the
+// instruction does not depend on its operands only.
+func evalConst(op vm.OpCode, args []symValue) *uint256.Int {
+	z := new(uint256.Int)
+	x := func(i int) *uint256.Int { return args[i].konst }
+	boolean := func(b bool) *uint256.Int {
+		if b {
+			return z.SetOne()
+		}
+		return z
+	}
+	shift := func(fn func(*uint256.Int, uint) *uint256.Int) *uint256.Int {
+		if !x(0).LtUint64(256) {
+			if op == vm.SAR && x(1).Sign() < 0 {
+				return z.SetAllOne()
+			}
+			return z
+		}
+		return fn(x(1), uint(x(0).Uint64()))
+	}
+	switch op {
+	case vm.ADD:
+		return z.Add(x(0), x(1))
+	case vm.MUL:
+		return z.Mul(x(0), x(1))
+	case vm.SUB:
+		return z.Sub(x(0), x(1))
+	case vm.DIV:
+		return z.Div(x(0), x(1))
+	case vm.SDIV:
+		return z.SDiv(x(0), x(1))
+	case vm.MOD:
+		return z.Mod(x(0), x(1))
+	case vm.SMOD:
+		return z.SMod(x(0), x(1))
+	case vm.ADDMOD:
+		return z.AddMod(x(0), x(1), x(2))
+	case vm.MULMOD:
+		return z.MulMod(x(0), x(1), x(2))
+	case vm.EXP:
+		return z.Exp(x(0), x(1))
+	case vm.SIGNEXTEND:
+		return z.ExtendSign(x(1), x(0))
+	case vm.LT:
+		return boolean(x(0).Lt(x(1)))
+	case vm.GT:
+		return boolean(x(0).Gt(x(1)))
+	case vm.SLT:
+		return boolean(x(0).Slt(x(1)))
+	case vm.SGT:
+		return boolean(x(0).Sgt(x(1)))
+	case vm.EQ:
+		return boolean(x(0).Eq(x(1)))
+	case vm.ISZERO:
+		return boolean(x(0).IsZero())
+	case vm.AND:
+		return z.And(x(0), x(1))
+	case vm.OR:
+		return z.Or(x(0), x(1))
+	case vm.XOR:
+		return z.Xor(x(0), x(1))
+	case vm.NOT:
+		return z.Not(x(0))
+	case vm.BYTE:
+		return z.Set(x(1)).Byte(x(0))
+	case vm.SHL:
+		return shift(z.Lsh)
+	case vm.SHR:
+		return shift(z.Rsh)
+	case vm.SAR:
+		return shift(z.SRsh)
+	}
+	return nil
+}
+
+// stackEffect returns the number of stack items an instruction consumes and produces,
+// ok is false for opcodes which are not defined in legacy code. DUP and SWAP are
+// handled by the emulator itself.
+func stackEffect(op vm.OpCode) (pops int, pushes int, ok bool) {
+	switch {
+	case op.IsPush():
+		return 0, 1, true
+	case op >= vm.LOG0 && op <= vm.LOG4:
+		return 2 + int(op-vm.LOG0), 0, true
+	}
+	switch op {
+	case vm.STOP, vm.JUMPDEST, vm.INVALID:
+		return 0, 0, true
+	case vm.ADDMOD, vm.MULMOD:
+		return 3, 1, true
+	case vm.ADD, vm.MUL, vm.SUB, vm.DIV, vm.SDIV, vm.MOD, vm.SMOD, vm.EXP, vm.SIGNEXTEND,
+		vm.LT, vm.GT, vm.SLT, vm.SGT, vm.EQ, vm.AND, vm.OR, vm.XOR, vm.BYTE, vm.SHL, vm.SHR, vm.SAR,
+		vm.KECCAK256:
+		return 2, 1, true
+	case vm.ISZERO, vm.NOT, vm.BALANCE, vm.CALLDATALOAD, vm.EXTCODESIZE, vm.EXTCODEHASH,
+		vm.BLOCKHASH, vm.BLOBHASH, vm.MLOAD, vm.SLOAD, vm.TLOAD:
+		return 1, 1, true
+	case vm.ADDRESS, vm.ORIGIN, vm.CALLER, vm.CALLVALUE, vm.CALLDATASIZE, vm.CODESIZE, vm.GASPRICE,
+		vm.RETURNDATASIZE, vm.COINBASE, vm.TIMESTAMP, vm.NUMBER, vm.DIFFICULTY, vm.GASLIMIT,
+		vm.CHAINID, vm.SELFBALANCE, vm.BASEFEE, vm.BLOBBASEFEE, vm.PC, vm.MSIZE, vm.GAS:
+		return 0, 1, true
+	case vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY, vm.MCOPY:
+		return 3, 0, true
+	case vm.EXTCODECOPY:
+		return 4, 0, true
+	case vm.POP, vm.JUMP, vm.SELFDESTRUCT:
+		return 1, 0, true
+	case vm.MSTORE, vm.MSTORE8, vm.SSTORE, vm.TSTORE, vm.JUMPI, vm.RETURN, vm.REVERT:
+		return 2, 0, true
+	case vm.CREATE:
+		return 3, 1, true
+	case vm.CREATE2:
+		return 4, 1, true
+	case vm.CALL, vm.CALLCODE:
+		return 7, 1, true
+	case vm.DELEGATECALL, vm.STATICCALL:
+		return 6, 1, true
+	}
+	return 0, 0, false
+}
+
+// selectorHooks tracks the selector extracted from the calldata and records the
+// constants it is compared against before a JUMPI.
+type selectorHooks struct {
+	baseHooks
+	dispatch *Dispatcher
+	seen     map[string]bool
+	pivots   map[string]bool
+}
+
+var (
+	selectorShift = uint256.NewInt(224)
+	selectorDiv   = new(uint256.Int).Lsh(uint256.NewInt(1), 224)
+	selectorMask  = uint256.NewInt(0xffffffff)
+)
+
+func (h *selectorHooks) result(st *emuState, in instruction, args []symValue, res symValue) symValue {
+	isCalldataHead := func(v symValue) bool { return v.tag == tagCalldata && v.ref.IsZero() }
+	isSelector := func(v symValue) bool { return v.tag == tagSelector }
+	// compared returns the constant the selector is compared against, in any operand order.
+	compared := func() (*uint256.Int, bool) {
+		if isSelector(args[0]) && args[1].isConst() {
+			return args[1].konst, true
+		}
+		if isSelector(args[1]) && args[0].isConst() {
+			return args[0].konst, true
+		}
+		return nil, false
+	}
+	switch in.op {
+	case vm.SHR:
+		if args[0].isConst() && args[0].konst.Eq(selectorShift) && isCalldataHead(args[1]) {
+			return taggedValue(tagSelector, nil)
+		}
+	case vm.DIV:
+		if isCalldataHead(args[0]) && args[1].isConst() && args[1].konst.Eq(selectorDiv) {
+			return taggedValue(tagSelector, nil)
+		}
+	case vm.AND:
+		// Masking an already extracted selector keeps it unchanged.
+		if val, ok := compared(); ok && val.Eq(selectorMask) {
+			return taggedValue(tagSelector, nil)
+		}
+	case vm.EQ:
+		if val, ok := compared(); ok {
+			return taggedValue(tagSelectorEq, val)
+		}
+	case vm.XOR, vm.SUB:
+		if val, ok := compared(); ok {
+			return taggedValue(tagSelectorNeq, val)
+		}
+	case vm.GT, vm.LT, vm.SGT, vm.SLT:
+		if val, ok := compared(); ok {
+			return taggedValue(tagSelectorCmp, val)
+		}
+	case vm.ISZERO:
+		switch args[0].tag {
+		case tagSelectorEq:
+			return taggedValue(tagSelectorNeq, args[0].ref)
+		case tagSelectorNeq:
+			return taggedValue(tagSelectorEq, args[0].ref)
+		case tagSelector:
+			return taggedValue(tagSelectorEq, new(uint256.Int))
+		}
+	}
+	return res
+}
+
+// selectorHex returns the constant as a 4-bytes hex selector, if it fits.
+func selectorHex(val *uint256.Int) (string, bool) {
+	if val.Gt(selectorMask) {
+		return "", false
+	}
+	return fmt.Sprintf("%08x", val.Uint64()), true
+}
+
+func (h *selectorHooks) addSelector(val *uint256.Int, entry uint64) {
+	id, ok := selectorHex(val)
+	if ok && !h.seen[id] {
+		h.seen[id] = true
+		h.dispatch.Selectors = append(h.dispatch.Selectors, Selector{ID: id, Entry: entry})
+	}
+}
+
+func (h *selectorHooks) jumpi(st *emuState, in instruction, dest, cond symValue) (bool, bool) {
+	switch cond.tag {
+	case tagSelectorEq:
+		// The taken branch is the function body, there is nothing more to dispatch there.
+		if dest.isConst() && dest.konst.IsUint64() {
+			h.addSelector(cond.ref, dest.konst.Uint64())
+		}
+		return false, true
+	case tagSelectorNeq:
+		h.addSelector(cond.ref, in.pc+1)
+		return true, false
+	case tagSelectorCmp:
+		if id, ok := selectorHex(cond.ref); ok && !h.pivots[id] {
+			h.pivots[id] = true
+			h.dispatch.Pivots = append(h.dispatch.Pivots, id)
+		}
+	}
+	return true, true
+}
+
+// EmulateDispatcher extracts the function selectors by abstract interpretation of the
+// bytecode instead of matching the dispatcher patterns. The value derived from
+// `CALLDATALOAD(0) >> 224` is tracked through the stack and memory, every constant it
+// is compared against before a JUMPI is recorded. This is robust against reordered
+// instructions, hand-written dispatchers and obfuscated code.
+func EmulateDispatcher(bytecode []byte) *Dispatcher {
+	hooks := &selectorHooks{
+		dispatch: &Dispatcher{Compiler: DetectCompiler(bytecode)},
+		seen:     make(map[string]bool),
+		pivots:   make(map[string]bool),
+	}
+	newEmulator(bytecode, hooks).run(newEmuState(0))
+	sort.Strings(hooks.dispatch.Pivots)
+	return hooks.dispatch
+}
@@ -0,0 +1,363 @@
+package dasm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+const (
+	eofVersion1 = 0x01
+
+	eofKindTypes     = 0x01
+	eofKindCode      = 0x02
+	eofKindContainer = 0x03
+	eofKindData      = 0x04
+
+	eofNonReturning      = 0x80
+	eofMaxCodeSections   = 1024
+	eofMaxContainers     = 256
+	eofTypeSectionLength = 4
+)
+
+var eofMagic = []byte{0xef, 0x00}
+
+var (
+	errEOFInvalidMagic   = errors.New("eof: invalid magic")
+	errEOFInvalidVersion = errors.New("eof: unsupported version")
+	errEOFTruncated      = errors.New("eof: truncated container")
+)
+
+// EOFTypeSection is the signature of an EOF code section.
+type EOFTypeSection struct {
+	Inputs         uint8
+	Outputs        uint8
+	MaxStackHeight uint16
+}
+
+// NonReturning returns true if the code section never returns to its caller.
+func (t EOFTypeSection) NonReturning() bool {
+	return t.Outputs == eofNonReturning
+}
+
+// EOFContainer is an EVM Object Format (EIP-3540) container split in its sections.
+type EOFContainer struct {
+	Version      uint8
+	Types        []EOFTypeSection
+	CodeSections [][]byte
+	Containers   []*EOFContainer // nested containers, e.g. the init code of EOFCREATE
+	Data         []byte
+	DataSize     int // declared size of the data section, might be more than len(Data) in nested containers
+}
+
+// IsEOF returns true if the code starts with the EOF magic.
+func IsEOF(code []byte) bool {
+	return bytes.HasPrefix(code, eofMagic)
+}
+
+// eofReader reads the big-endian fields of an EOF header.
+type eofReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *eofReader) byte() (byte, error) {
+	if r.pos >= len(r.b) {
+		return 0, errEOFTruncated
+	}
+	r.pos++
+	return r.b[r.pos-1], nil
+}
+
+func (r *eofReader) uint16() (int, error) {
+	if r.pos+2 > len(r.b) {
+		return 0, errEOFTruncated
+	}
+	r.pos += 2
+	return int(binary.BigEndian.Uint16(r.b[r.pos-2:])), nil
+}
+
+func (r *eofReader) expect(kind byte) error {
+	b, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if b != kind {
+		return fmt.Errorf("eof: expected section kind %#x at offset %d, found %#x", kind, r.pos-1, b)
+	}
+	return nil
+}
+
+// sizes reads a section list header, a count followed by the size of every section.
+func (r *eofReader) sizes(max int) ([]int, error) {
+	count, err := r.uint16()
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 || count > max {
+		return nil, fmt.Errorf("eof: invalid number of sections %d", count)
+	}
+	list := make([]int, count)
+	for i := range list {
+		if list[i], err = r.uint16(); err != nil {
+			return nil, err
+		}
+		if list[i] == 0 {
+			return nil, fmt.Errorf("eof: empty section %d", i)
+		}
+	}
+	return list, nil
+}
+
+// ParseEOFContainer validates the header of an EOF container and splits its sections.
+func ParseEOFContainer(code []byte) (*EOFContainer, error) {
+	return parseEOFContainer(code, true)
+}
+
+func parseEOFContainer(code []byte, topLevel bool) (*EOFContainer, error) {
+	if !IsEOF(code) {
+		return nil, errEOFInvalidMagic
+	}
+	r := &eofReader{b: code, pos: len(eofMagic)}
+	version, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	if version != eofVersion1 {
+		return nil, fmt.Errorf("%w %d", errEOFInvalidVersion, version)
+	}
+
+	// Header
+	if err := r.expect(eofKindTypes); err != nil {
+		return nil, err
+	}
+	typesSize, err := r.uint16()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.expect(eofKindCode); err != nil {
+		return nil, err
+	}
+	codeSizes, err := r.sizes(eofMaxCodeSections)
+	if err != nil {
+		return nil, err
+	}
+	if typesSize != len(codeSizes)*eofTypeSectionLength {
+		return nil, fmt.Errorf("eof: type section size %d mismatch %d code sections", typesSize, len(codeSizes))
+	}
+	kind, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	var containerSizes []int
+	if kind == eofKindContainer {
+		if containerSizes, err = r.sizes(eofMaxContainers); err != nil {
+			return nil, err
+		}
+		if kind, err = r.byte(); err != nil {
+			return nil, err
+		}
+	}
+	if kind != eofKindData {
+		return nil, fmt.Errorf("eof: expected data section kind at offset %d, found %#x", r.pos-1, kind)
+	}
+	dataSize, err := r.uint16()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.expect(0x00); err != nil {
+		return nil, fmt.Errorf("eof: missing header terminator: %w", err)
+	}
+
+	// Body
+	c := &EOFContainer{Version: version, DataSize: dataSize}
+	body := func(size int) ([]byte, error) {
+		if r.pos+size > len(code) {
+			return nil, errEOFTruncated
+		}
+		r.pos += size
+		return code[r.pos-size : r.pos], nil
+	}
+	for range codeSizes {
+		section, err := body(eofTypeSectionLength)
+		if err != nil {
+			return nil, err
+		}
+		c.Types = append(c.Types, EOFTypeSection{
+			Inputs:         section[0],
+			Outputs:        section[1],
+			MaxStackHeight: binary.BigEndian.Uint16(section[2:]),
+		})
+	}
+	if c.Types[0].Inputs != 0 || !c.Types[0].NonReturning() {
+		return nil, fmt.Errorf("eof: first code section must have 0 inputs and be non-returning")
+	}
+	for _, size := range codeSizes {
+		section, err := body(size)
+		if err != nil {
+			return nil, err
+		}
+		c.CodeSections = append(c.CodeSections, section)
+	}
+	for i, size := range containerSizes {
+		section, err := body(size)
+		if err != nil {
+			return nil, err
+		}
+		sub, err := parseEOFContainer(section, false)
+		if err != nil {
+			return nil, fmt.Errorf("eof: invalid container section %d: %w", i, err)
+		}
+		c.Containers = append(c.Containers, sub)
+	}
+	c.Data = code[r.pos:]
+	switch {
+	case len(c.Data) > dataSize:
+		return nil, fmt.Errorf("eof: data section size %d exceeds declared size %d", len(c.Data), dataSize)
+	case len(c.Data) < dataSize && topLevel:
+		// Only nested containers may have their data section filled in at deploy time.
+		return nil, errEOFTruncated
+	}
+	return c, nil
+}
+
+// eofTargets returns the annotation of the instruction targets: the absolute PC of
+// relative jumps or the index of the called code section.
+func eofTargets(pc uint64, op vm.OpCode, arg []byte) string {
+	next := int64(pc) + 1 + int64(len(arg))
+	relative := func(off []byte) string {
+		return fmt.Sprintf("%#x", next+int64(int16(binary.BigEndian.Uint16(off))))
+	}
+	switch op {
+	case vm.RJUMP, vm.RJUMPI:
+		return relative(arg)
+	case vm.RJUMPV:
+		targets := make([]string, 0, len(arg)/2)
+		for i := 1; i+1 < len(arg); i += 2 {
+			targets = append(targets, relative(arg[i:i+2]))
+		}
+		return strings.Join(targets, ",")
+	case vm.CALLF, vm.JUMPF:
+		return fmt.Sprintf("code section %d", binary.BigEndian.Uint16(arg))
+	case vm.EOFCREATE, vm.RETURNCONTRACT:
+		return fmt.Sprintf("container section %d", arg[0])
+	}
+	return ""
+}
+
+// disassemble appends the disassembled sections of the container to the list,
+// `prefix` identifies nested containers.
+func (c *EOFContainer) disassemble(instrs []string, prefix string) ([]string, error) {
+	for i, code := range c.CodeSections {
+		ty := c.Types[i]
+		outputs := fmt.Sprint(ty.Outputs)
+		if ty.NonReturning() {
+			outputs = "non-returning"
+		}
+		instrs = append(instrs, fmt.Sprintf("; %scode section %d: inputs %d, outputs %s, max stack height %d\n",
+			prefix, i, ty.Inputs, outputs, ty.MaxStackHeight))
+		it := NewEOFInstructionIterator(code)
+		for it.Next() {
+			line := fmt.Sprintf("%05x: %v", it.PC(), it.Op())
+			if len(it.Arg()) > 0 {
+				line += fmt.Sprintf(" %#x", it.Arg())
+			}
+			if target := eofTargets(it.PC(), it.Op(), it.Arg()); target != "" {
+				line += " ; -> " + target
+			}
+			instrs = append(instrs, line+"\n")
+		}
+		if err := it.Error(); err != nil {
+			return nil, fmt.Errorf("%scode section %d: %w", prefix, i, err)
+		}
+	}
+	for i, sub := range c.Containers {
+		var err error
+		if instrs, err = sub.disassemble(instrs, fmt.Sprintf("%scontainer %d ", prefix, i)); err != nil {
+			return nil, err
+		}
+	}
+	if len(c.Data) > 0 || c.DataSize > 0 {
+		instrs = append(instrs, fmt.Sprintf("; %sdata section: %d bytes, declared %d\n", prefix, len(c.Data), c.DataSize))
+		if len(c.Data) > 0 {
+			instrs = append(instrs, fmt.Sprintf(".data %#x\n", c.Data))
+		}
+	}
+	return instrs, nil
+}
+
+// Disassemble returns the disassembled code sections of the container and its nested
+// containers in human-readable format, with the targets of relative jumps and the code
+// sections of CALLF/JUMPF resolved.
+func (c *EOFContainer) Disassemble() ([]string, error) {
+	return c.disassemble(make([]string, 0), "")
+}
+
+// codeIterators returns an iterator over every code section of an EOF container, or a
+// single iterator over legacy code without its metadata.
+func codeIterators(bytecode []byte) []*instructionIterator {
+	if !IsEOF(bytecode) {
+		return []*instructionIterator{NewInstructionIterator(StripMetadata(bytecode))}
+	}
+	c, err := ParseEOFContainer(bytecode)
+	if err != nil {
+		return nil
+	}
+	its := make([]*instructionIterator, len(c.CodeSections))
+	for i, code := range c.CodeSections {
+		its[i] = NewEOFInstructionIterator(code)
+	}
+	return its
+}
+
+// matchEOFSelector matches the selector comparisons of EOF dispatchers, which branch
+// with a relative jump instead of a pushed jump destination.
+func matchEOFSelector(ins []instruction) bool {
+	return matchPattern(ins, []matcherFn{
+		opAnyOf(vm.DUP1, vm.DUP2),
+		opAnyOf(vm.PUSH0, vm.PUSH1, vm.PUSH2, vm.PUSH3, vm.PUSH4),
+		opExact(vm.EQ),
+		opExact(vm.RJUMPI),
+	}) || matchPattern(ins, []matcherFn{
+		opAnyOf(vm.PUSH0, vm.PUSH1, vm.PUSH2, vm.PUSH3, vm.PUSH4),
+		opAnyOf(vm.DUP1, vm.DUP2, vm.DUP3),
+		opExact(vm.EQ),
+		opExact(vm.RJUMPI),
+	})
+}
+
+// parseEOFDispatcher extracts the selectors compared in the code sections of an EOF container,
+// selector entries are PCs within the code section of the comparison. Comparisons jumping
+// out of their code section are skipped.
+func parseEOFDispatcher(bytecode []byte) *Dispatcher {
+	dispatch := &Dispatcher{Compiler: CompilerSolc}
+	container, err := ParseEOFContainer(bytecode)
+	if err != nil {
+		return dispatch
+	}
+	seen := make(map[string]bool)
+	for _, code := range container.CodeSections {
+		it := NewEOFInstructionIterator(code)
+		for it.Next() {
+			ins := it.Instructions(4)
+			if !matchEOFSelector(ins) {
+				continue
+			}
+			push := ins[1]
+			if ins[0].op.IsPush() {
+				push = ins[0]
+			}
+			id := selectorOf(push)
+			target := int64(ins[3].pc) + 3 + int64(int16(binary.BigEndian.Uint16(ins[3].arg)))
+			if !seen[id] && target >= 0 && target < int64(len(code)) {
+				seen[id] = true
+				dispatch.Selectors = append(dispatch.Selectors, Selector{ID: id, Entry: uint64(target)})
+			}
+		}
+	}
+	return dispatch
+}
@@ -0,0 +1,84 @@
+package dasm
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var eofTestContainer = hexutil.MustDecode("0x" +
+	"ef0001" + // magic and version
+	"010008" + // types section, 2 code sections
+	"02000200140001" + // code sections of 20 and 1 bytes
+	"0300010014" + // 1 container section of 20 bytes
+	"040002" + // data section of 2 bytes
+	"00" + // header terminator
+	"00800002" + "00000000" + // types
+	"5f3560e01c806306fdde0314e1000100e3000100" + // code section 0
+	"e4" + // code section 1: RETF
+	"ef00010100040200010001040000" + "00" + "00800000" + "00" + // nested container
+	"aabb") // data
+
+func TestParseEOFContainer(t *testing.T) {
+	c, err := ParseEOFContainer(eofTestContainer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Types) != 2 || len(c.CodeSections) != 2 || len(c.Containers) != 1 {
+		t.Fatalf("unexpected sections: %d types, %d code, %d containers", len(c.Types), len(c.CodeSections), len(c.Containers))
+	}
+	if !c.Types[0].NonReturning() || c.Types[0].MaxStackHeight != 2 {
+		t.Fatalf("unexpected type section %+v", c.Types[0])
+	}
+	if hexutil.Encode(c.Data) != "0xaabb" || c.DataSize != 2 {
+		t.Fatalf("unexpected data section %x", c.Data)
+	}
+	lines, err := Disassemble(eofTestContainer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listing := strings.Join(lines, "")
+	for _, want := range []string{
+		"0000c: RJUMPI 0x0001 ; -> 0x10\n",
+		"00010: CALLF 0x0001 ; -> code section 1\n",
+		"00000: RETF\n",
+		"; container 0 code section 0",
+		".data 0xaabb\n",
+	} {
+		if !strings.Contains(listing, want) {
+			t.Errorf("disassembly is missing %q:\n%s", want, listing)
+		}
+	}
+	assertSelectors(t, ParseFunctionSelectors(eofTestContainer), "06fdde03")
+}
+
+func TestParseEOFContainerInvalid(t *testing.T) {
+	invalid := map[string][]byte{
+		"version":   append([]byte{0xef, 0x00, 0x02}, eofTestContainer[3:]...),
+		"truncated": eofTestContainer[:len(eofTestContainer)-1],
+		"trailing":  append(append([]byte{}, eofTestContainer...), 0x00),
+		"header":    eofTestContainer[:10],
+	}
+	for name, code := range invalid {
+		if _, err := ParseEOFContainer(code); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestParseEOFDispatcher(t *testing.T) {
+	if selectors := parseEOFDispatcher(eofTestContainer).Selectors; len(selectors) != 1 || selectors[0] != (Selector{ID: "06fdde03", Entry: 0x10}) {
+		t.Errorf("unexpected selectors %+v", selectors)
+	}
+	// RJUMPI -32 jumps before the start of the code section.
+	container := slices.Clone(eofTestContainer)
+	offset := bytes.Index(container, common.FromHex("e10001"))
+	copy(container[offset:], common.FromHex("e1ffe0"))
+	if selectors := parseEOFDispatcher(container).Selectors; len(selectors) != 0 {
+		t.Errorf("unexpected selectors jumping out of the section %+v", selectors)
+	}
+}
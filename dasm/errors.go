@@ -0,0 +1,142 @@
+package dasm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+const (
+	errorStringSelector = "08c379a0" // Error(string)
+	panicSelectorHex    = "4e487b71" // Panic(uint256)
+	maxReasonWindow     = 64         // instructions scanned after the Error(string) selector
+)
+
+// RevertInfo holds the errors a contract reverts with.
+type RevertInfo struct {
+	Selectors []string // 4-bytes selectors of the custom errors
+	Reasons   []string // reason strings of the Error(string) reverts
+}
+
+// revertHooks records the selectors of the revert data whose head is known.
+type revertHooks struct {
+	baseHooks
+	selectors map[string]bool
+}
+
+func (h *revertHooks) step(st *emuState, in instruction, args []symValue) {
+	if in.op != vm.REVERT || !args[0].isConst() || !args[1].isConst() ||
+		!args[0].konst.IsUint64() || !args[1].konst.IsUint64() || args[1].konst.Uint64() < 4 {
+		return
+	}
+	if head, ok := st.memory(args[0].konst.Uint64(), 4); ok {
+		h.selectors[hex.EncodeToString(head)] = true
+	}
+}
+
+// parseErrorSelectors emulates the bytecode and returns the selectors of the custom
+// errors written to memory before a REVERT, the builtin Error and Panic are excluded.
+func parseErrorSelectors(bytecode []byte) []string {
+	if IsEOF(bytecode) {
+		return []string{}
+	}
+	hooks := &revertHooks{selectors: make(map[string]bool)}
+	newEmulator(bytecode, hooks).run(newEmuState(0))
+	ret := make([]string, 0, len(hooks.selectors))
+	for id := range hooks.selectors {
+		if id != errorStringSelector && id != panicSelectorHex {
+			ret = append(ret, id)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+var (
+	errorStringHead    = []byte{0x08, 0xc3, 0x79, 0xa0}
+	errorStringShifted = []byte{0x46, 0x1b, 0xcd} // Error(string) selector >> 5, shifted back with SHL 0xe5
+)
+
+// isErrorStringSelector tells whether the pushed constant builds the Error(string) selector.
+func isErrorStringSelector(arg []byte) bool {
+	arg = bytes.TrimLeft(arg, "\x00")
+	return bytes.Equal(arg, errorStringShifted) ||
+		(bytes.HasPrefix(arg, errorStringHead) && len(bytes.Trim(arg[len(errorStringHead):], "\x00")) == 0)
+}
+
+// pushedText returns the pushed constant as text if it is made of printable characters.
+func pushedText(arg []byte) (string, bool) {
+	arg = bytes.Trim(arg, "\x00")
+	if len(arg) == 0 {
+		return "", false
+	}
+	for _, c := range arg {
+		if c < 0x20 || c > 0x7e {
+			return "", false
+		}
+	}
+	return string(arg), true
+}
+
+// parseReasons returns the reason strings pushed after the Error(string) selector up to
+// the REVERT. Reasons longer than 32 bytes are pushed in chunks of 32 bytes.
+func parseReasons(bytecode []byte) []string {
+	reasons := make(map[string]bool)
+	for _, it := range codeIterators(bytecode) {
+		window := 0
+		reason, full := "", false
+		flush := func() {
+			if reason != "" {
+				reasons[reason] = true
+			}
+			reason, full = "", false
+		}
+		for it.Next() {
+			in := it.Instruction()
+			switch {
+			case in.op.IsPush() && isErrorStringSelector(in.arg):
+				flush()
+				window = maxReasonWindow
+				continue
+			case window == 0:
+				continue
+			case in.op == vm.REVERT:
+				window = 1
+			case in.op.IsPush():
+				// Short texts are usually offsets or jump targets which happen to be
+				// printable, unless they are left aligned like the chunks of reasons.
+				text, ok := pushedText(in.arg)
+				long := len(text) >= 3 || (in.op == vm.PUSH32 && in.arg[0] != 0)
+				if !ok || !long {
+					break
+				}
+				if !full {
+					flush()
+				}
+				reason += text
+				full = len(text) == 32
+			}
+			window--
+			if window == 0 {
+				flush()
+			}
+		}
+	}
+	ret := make([]string, 0, len(reasons))
+	for reason := range reasons {
+		ret = append(ret, reason)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// ParseErrors returns the custom error selectors written to memory before a REVERT and
+// the reason strings of the Error(string) reverts built from the pushed constants.
+func ParseErrors(bytecode []byte) *RevertInfo {
+	return &RevertInfo{
+		Selectors: parseErrorSelectors(bytecode),
+		Reasons:   parseReasons(bytecode),
+	}
+}
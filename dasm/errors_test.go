@@ -0,0 +1,71 @@
+package dasm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	code := assemble(t, `
+		PUSH1 0x80 PUSH1 0x40 MSTORE
+		CALLVALUE PUSH2 @transfer JUMPI
+		PUSH4 0xdeadbeef PUSH1 0xe0 SHL PUSH0 MSTORE PUSH1 0x04 PUSH0 REVERT
+		transfer: JUMPDEST
+		PUSH1 0x40 MLOAD PUSH3 0x461bcd PUSH1 0xe5 SHL DUP2 MSTORE
+		PUSH1 0x20 PUSH1 0x04 DUP3 ADD MSTORE PUSH1 0x26 PUSH1 0x24 DUP3 ADD MSTORE
+		PUSH32 0x45524332303a207472616e7366657220616d6f756e7420657863656564732062 PUSH1 0x44 DUP3 ADD MSTORE
+		PUSH6 0x616c616e6365 PUSH1 0xd0 SHL PUSH1 0x64 DUP3 ADD MSTORE
+		PUSH1 0x84 ADD PUSH1 0x40 MLOAD DUP1 SWAP2 SUB SWAP1 REVERT
+		PUSH4 0x08c379a0 PUSH1 0xe0 SHL PUSH0 MSTORE
+		PUSH32 0x4f776e61626c653a2063616c6c6572206973206e6f7420746865206f776e6572 PUSH1 0x44 MSTORE
+		PUSH1 0x64 PUSH0 REVERT`)
+	reverts := ParseErrors(code)
+	if strings.Join(reverts.Selectors, ",") != "deadbeef" {
+		t.Errorf("unexpected error selectors %v", reverts.Selectors)
+	}
+	expected := "ERC20: transfer amount exceeds balance,Ownable: caller is not the owner"
+	if strings.Join(reverts.Reasons, ",") != expected {
+		t.Errorf("unexpected revert reasons %q", reverts.Reasons)
+	}
+}
+
+func TestInterfaceErrors(t *testing.T) {
+	intf := loadInterface(t, "Vault", `[
+		{"type":"constructor","inputs":[{"name":"owner","type":"address"}],"stateMutability":"nonpayable"},
+		{"type":"receive","stateMutability":"payable"},
+		{"type":"function","name":"withdraw","inputs":[{"name":"amount","type":"uint256"}],"stateMutability":"nonpayable"},
+		{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}
+	]`)
+	errorID := FourBytesSigOf("InsufficientBalance(uint256,uint256)")
+	if _, ok := intf.Errors["InsufficientBalance"]; !ok || len(intf.ErrorElements) != 1 {
+		t.Errorf("missing custom error %v", intf.Errors)
+	}
+	if len(intf.Constructor.Inputs) != 1 || !intf.HasReceive() {
+		t.Errorf("missing constructor or receive %v", intf.privateABI)
+	}
+	sigs := GetErrorSigsByID(errorID, []Interface{intf})
+	if len(sigs) != 1 || sigs[0] != "InsufficientBalance(uint256,uint256)" {
+		t.Errorf("unexpected error signatures %v", sigs)
+	}
+	matched := MatchInterfaces([]Interface{intf}, []string{FourBytesSigOf("withdraw(uint256)"), errorID})
+	if len(matched) != 1 || matched[0].Matched != 1 || matched[0].ErrorsMatched != 1 || matched[0].Confidence != 1 {
+		t.Errorf("unexpected matched interfaces %+v", matched)
+	}
+	// Custom errors not found by the revert analysis do not lower the confidence.
+	matched = MatchInterfaces([]Interface{intf}, []string{FourBytesSigOf("withdraw(uint256)")})
+	if len(matched) != 1 || matched[0].Matched != 1 || matched[0].ErrorsMatched != 0 || matched[0].Confidence != 1 {
+		t.Errorf("unexpected matched interfaces without errors %+v", matched)
+	}
+}
+
+func TestParseErrorsCompiled(t *testing.T) {
+	reverts := ParseErrors(loadFixture(t, "solc-0.8.7-errors.hex"))
+	if strings.Join(reverts.Selectors, ",") != FourBytesSigOf("MyError3(uint256,uint256,uint256)") {
+		t.Errorf("unexpected error selectors %v", reverts.Selectors)
+	}
+	reverts = ParseErrors(loadFixture(t, "solc-0.6.6-ballot.hex"))
+	expected := "Already voted.,Found loop in delegation.,Has no right to vote,Self-delegation is disallowed.,The voter already voted.,You already voted."
+	if len(reverts.Selectors) != 0 || strings.Join(reverts.Reasons, ",") != expected {
+		t.Errorf("unexpected revert reasons %q", reverts.Reasons)
+	}
+}
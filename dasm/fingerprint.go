@@ -0,0 +1,212 @@
+package dasm
+
+import (
+	"bytes"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+const (
+	OptimizerUnknown  = "unknown"
+	OptimizerEnabled  = "enabled"
+	OptimizerViaIR    = "via-ir"
+	OptimizerGas      = "gas"
+	OptimizerCodesize = "codesize"
+)
+
+var panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+
+// CompilerInfo is the best guess of the compiler and settings that produced a bytecode.
+type CompilerInfo struct {
+	Compiler   Compiler
+	Version    string   // exact version read from the metadata, empty if unknown
+	MinVersion string   // inclusive lower bound of the version, empty if unbounded
+	MaxVersion string   // exclusive upper bound of the version, empty if unbounded
+	Optimizer  string   // optimizer settings, one of the Optimizer* values
+	Evidence   []string // heuristics the guess is based on
+}
+
+// VersionRange returns the exact version or the version range of the compiler.
+func (c *CompilerInfo) VersionRange() string {
+	if c.Version != "" {
+		return c.Version
+	}
+	bounds := make([]string, 0, 2)
+	if c.MinVersion != "" {
+		bounds = append(bounds, ">="+c.MinVersion)
+	}
+	if c.MaxVersion != "" {
+		bounds = append(bounds, "<"+c.MaxVersion)
+	}
+	if len(bounds) == 0 {
+		return "unknown"
+	}
+	return strings.Join(bounds, " ")
+}
+
+// since narrows the version range to versions from `version` on.
+func (c *CompilerInfo) since(version string, evidence string) {
+	if c.MinVersion == "" || compareVersions(version, c.MinVersion) > 0 {
+		c.MinVersion = version
+	}
+	c.Evidence = append(c.Evidence, evidence)
+}
+
+// before narrows the version range to versions before `version`.
+func (c *CompilerInfo) before(version string, evidence string) {
+	if c.MaxVersion == "" || compareVersions(version, c.MaxVersion) < 0 {
+		c.MaxVersion = version
+	}
+	c.Evidence = append(c.Evidence, evidence)
+}
+
+// compareVersions compares two dotted versions numerically, missing parts are zero.
+func compareVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+		if na != nb {
+			return na - nb
+		}
+	}
+	return 0
+}
+
+// codeFeatures are the instruction level hints of the compiler version.
+type codeFeatures struct {
+	push0       bool // PUSH0, only emitted for shanghai and later
+	panic       bool // Panic(uint256) selector of checked arithmetic
+	shrSelector bool // selector extracted with `PUSH1 0xe0 SHR`
+	divSelector bool // selector extracted with `PUSH29 0x01.. DIV`
+	entryLoad   bool // selector extracted at the entry without memory setup
+}
+
+func scanFeatures(code []byte) codeFeatures {
+	var f codeFeatures
+	it := NewInstructionIterator(code)
+	for it.Next() {
+		in := it.Instruction()
+		switch {
+		case in.op == vm.PUSH0:
+			f.push0 = true
+		case in.op.IsPush() && (bytes.Equal(in.arg, panicSelector) || (len(in.arg) == 32 && bytes.HasPrefix(in.arg, panicSelector))):
+			f.panic = true
+		case in.op == vm.SHR || in.op == vm.DIV:
+			var load, shift, div bool
+			for _, prev := range it.Instructions(4) {
+				switch {
+				case prev.op == vm.CALLDATALOAD:
+					load = true
+				case prev.op == vm.PUSH1 && prev.arg[0] == 0xe0:
+					shift = true
+				case prev.op == vm.PUSH29 && prev.arg[0] == 0x01:
+					div = true
+				}
+			}
+			if load && shift && in.op == vm.SHR {
+				f.shrSelector = true
+				f.entryLoad = f.entryLoad || len(it.ins) <= 6
+			} else if load && div && in.op == vm.DIV {
+				f.divSelector = true
+			}
+		}
+	}
+	return f
+}
+
+// Fingerprint guesses the compiler, its version range and the optimizer settings from
+// the metadata, the dispatcher shape and instruction level hints. The metadata gives the
+// exact version when present, the other hints still bound the version when it is stripped.
+func Fingerprint(bytecode []byte) *CompilerInfo {
+	info := &CompilerInfo{Compiler: DetectCompiler(bytecode), Optimizer: OptimizerUnknown}
+	metadata, _ := ParseMetadata(bytecode)
+	features := scanFeatures(StripMetadata(bytecode))
+	dispatch := ParseDispatcher(bytecode)
+	hasPattern := func(prefix string) bool {
+		return slices.ContainsFunc(dispatch.Patterns, func(name string) bool { return strings.HasPrefix(name, prefix) })
+	}
+
+	if metadata != nil && metadata.Version != "" {
+		info.Version = metadata.Version
+		info.Evidence = append(info.Evidence, "compiler version in metadata")
+	}
+	if info.Compiler == CompilerUnknown && metadata == nil && features.entryLoad {
+		info.Compiler = CompilerHuff
+		info.Evidence = append(info.Evidence, "selector loaded at entry without memory setup or metadata")
+	}
+
+	switch info.Compiler {
+	case CompilerSolc:
+		switch {
+		case bytes.HasPrefix(bytecode, solcPreludes[0]):
+			info.since("0.4.22", "free memory pointer at 0x80")
+		case bytes.HasPrefix(bytecode, solcPreludes[1]):
+			info.before("0.4.22", "free memory pointer at 0x60")
+		}
+		if metadata != nil {
+			switch {
+			case metadata.IPFS != "":
+				info.since("0.6.0", "ipfs hash in metadata")
+			case metadata.Fields["bzzr1"] != nil:
+				info.since("0.5.12", "bzzr1 hash in metadata")
+				info.before("0.6.0", "bzzr1 hash in metadata")
+			case metadata.Fields["bzzr0"] != nil && metadata.Fields["solc"] == nil:
+				info.since("0.4.7", "bzzr0 hash in metadata")
+				info.before("0.5.9", "no compiler version in metadata")
+			}
+		}
+		if features.shrSelector {
+			info.since("0.5.0", "selector extracted with SHR")
+		}
+		if features.panic {
+			info.since("0.8.0", "Panic(uint256) error")
+		}
+		if features.push0 {
+			info.since("0.8.20", "PUSH0 instruction")
+		}
+		switch {
+		case hasPattern("solc-viair"):
+			info.Optimizer = OptimizerViaIR
+			info.since("0.8.13", "via-IR dispatcher")
+		case hasPattern("solc-optimized"):
+			info.Optimizer = OptimizerEnabled
+			info.Evidence = append(info.Evidence, "optimized dispatcher")
+		}
+	case CompilerVyper:
+		if metadata != nil {
+			if metadata.Raw[0]>>5 == 4 { // CBOR array of the code layout and the version map
+				info.since("0.3.10", "code layout in metadata")
+			}
+		}
+		switch {
+		case hasPattern("vyper-legacy"):
+			info.before("0.3.0", "selector kept in memory")
+		case hasPattern("vyper-xor"):
+			info.since("0.3.0", "selector compared with XOR")
+		}
+		if features.push0 {
+			info.since("0.3.8", "PUSH0 instruction")
+		}
+		switch {
+		case hasPattern("vyper-dense-table"):
+			info.Optimizer = OptimizerCodesize
+			info.since("0.3.10", "dense selector table")
+		case hasPattern("vyper-sparse-table"):
+			info.Optimizer = OptimizerGas
+			info.since("0.3.10", "sparse selector table")
+		}
+	}
+	if features.divSelector {
+		info.Evidence = append(info.Evidence, "selector extracted with DIV, pre-constantinople target")
+	}
+	return info
+}
@@ -0,0 +1,42 @@
+package dasm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     []byte
+		compiler Compiler
+		version  string
+	}{
+		{
+			name:     "solc metadata",
+			code:     hexutil.MustDecode("0x6080604052600080fdfe" + "a2" + "6469706673" + "5822" + "1220" + strings.Repeat("11", 32) + "64736f6c6343" + "000813" + "0033"),
+			compiler: CompilerSolc,
+			version:  "0.8.19",
+		},
+		{
+			name:     "solc stripped",
+			code:     assemble(t, "PUSH1 0x80 PUSH1 0x40 MSTORE PUSH0 CALLDATALOAD PUSH1 0xe0 SHR PUSH4 0x4e487b71 PUSH0 MSTORE"),
+			compiler: CompilerSolc,
+			version:  ">=0.8.20",
+		},
+		{
+			name:     "huff",
+			code:     assemble(t, "PUSH0 CALLDATALOAD PUSH1 0xe0 SHR DUP1 PUSH4 0x06fdde03 EQ PUSH2 @name JUMPI PUSH0 DUP1 REVERT name: JUMPDEST STOP"),
+			compiler: CompilerHuff,
+			version:  "unknown",
+		},
+	}
+	for _, tt := range tests {
+		info := Fingerprint(tt.code)
+		if info.Compiler != tt.compiler || info.VersionRange() != tt.version {
+			t.Errorf("%s: have %s %s, want %s %s (%v)", tt.name, info.Compiler, info.VersionRange(), tt.compiler, tt.version, info.Evidence)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package dasm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// loadFixture returns the bytecode of a hex fixture of testdata, see testdata/README.md.
+func loadFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := DecodeBytecode(data)
+	if err != nil {
+		t.Fatalf("invalid fixture %s: %v", name, err)
+	}
+	return code
+}
+
+// loadInterface returns the interface of an ABI JSON.
+func loadInterface(t *testing.T, name string, abiJSON string) Interface {
+	t.Helper()
+	elems := make([]ABIElement, 0)
+	if err := json.Unmarshal([]byte(abiJSON), &elems); err != nil {
+		t.Fatal(err)
+	}
+	intf, err := NewInterface(name, elems)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return intf
+}
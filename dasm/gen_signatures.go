@@ -0,0 +1,26 @@
+//go:build ignore
+
+// gen_signatures builds the bundled signature database from signatures.txt.
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/khanghh/contract-info/dasm"
+)
+
+func main() {
+	f, err := os.Open("signatures.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	db := dasm.NewSignatureDB()
+	if _, err := db.ImportDump(f, dasm.KindFunction); err != nil {
+		log.Fatal(err)
+	}
+	if err := dasm.SaveSignatureDB(db, "signatures.db"); err != nil {
+		log.Fatal(err)
+	}
+}
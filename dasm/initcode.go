@@ -0,0 +1,86 @@
+package dasm
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+var ErrNoRuntime = errors.New("could not locate the returned runtime code")
+
+// InitCode is the result of the analysis of a contract creation code.
+type InitCode struct {
+	Runtime         []byte // runtime bytecode returned by the init code
+	RuntimeOffset   uint64 // offset of the runtime bytecode in the init code
+	ArgsOffset      uint64 // offset of the constructor arguments in the init code
+	ConstructorArgs []byte // ABI encoded constructor arguments appended to the init code
+}
+
+// codeCopy is a CODECOPY with constant operands.
+type codeCopy struct {
+	dst, src, size uint64
+}
+
+// initCodeHooks records the CODECOPY instructions and the copy returned by RETURN.
+type initCodeHooks struct {
+	baseHooks
+	copies  []codeCopy
+	runtime *codeCopy
+}
+
+func (h *initCodeHooks) step(st *emuState, in instruction, args []symValue) {
+	constant := func(v symValue) (uint64, bool) {
+		if !v.isConst() || !v.konst.IsUint64() {
+			return 0, false
+		}
+		return v.konst.Uint64(), true
+	}
+	switch in.op {
+	case vm.CODECOPY:
+		dst, ok1 := constant(args[0])
+		src, ok2 := constant(args[1])
+		size, ok3 := constant(args[2])
+		if ok1 && ok2 && ok3 && size > 0 {
+			h.copies = append(h.copies, codeCopy{dst, src, size})
+		}
+	case vm.RETURN:
+		offset, ok1 := constant(args[0])
+		size, ok2 := constant(args[1])
+		if !ok1 || !ok2 || size == 0 || h.runtime != nil {
+			return
+		}
+		// The most recent copy to the returned memory is the runtime code, the
+		// immutables might have been written over it since.
+		for i := len(h.copies) - 1; i >= 0; i-- {
+			c := h.copies[i]
+			if c.dst == offset && c.size >= size {
+				h.runtime = &codeCopy{dst: offset, src: c.src, size: size}
+				return
+			}
+		}
+	}
+}
+
+// ParseInitCode emulates the creation code to locate the `CODECOPY ... RETURN` sequence
+// returning the runtime code. The constructor arguments are read by the constructor with
+// a CODECOPY up to the end of the code, or follow the runtime code otherwise.
+func ParseInitCode(initcode []byte) (*InitCode, error) {
+	hooks := &initCodeHooks{}
+	newEmulator(initcode, hooks).run(newEmuState(0))
+	if hooks.runtime == nil || hooks.runtime.src+hooks.runtime.size > uint64(len(initcode)) {
+		return nil, ErrNoRuntime
+	}
+	ret := &InitCode{
+		Runtime:       initcode[hooks.runtime.src : hooks.runtime.src+hooks.runtime.size],
+		RuntimeOffset: hooks.runtime.src,
+		ArgsOffset:    hooks.runtime.src + hooks.runtime.size,
+	}
+	for _, c := range hooks.copies {
+		if c.src != ret.RuntimeOffset && c.src+c.size == uint64(len(initcode)) {
+			ret.ArgsOffset = c.src
+			break
+		}
+	}
+	ret.ConstructorArgs = initcode[ret.ArgsOffset:]
+	return ret, nil
+}
@@ -0,0 +1,32 @@
+package dasm
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestParseInitCode(t *testing.T) {
+	args := strings.Repeat("00", 31) + "2a"
+	initcode := assemble(t, `
+		CALLVALUE DUP1 ISZERO PUSH2 @ok JUMPI PUSH0 DUP1 REVERT
+		ok: JUMPDEST POP
+		PUSH2 @args CODESIZE SUB PUSH2 @args PUSH1 0x80 CODECOPY
+		PUSH1 0x06 DUP1 PUSH2 @runtime PUSH0 CODECOPY PUSH0 RETURN INVALID
+		runtime: #600160005500
+		args: #`+args)
+	ret, err := ParseInitCode(initcode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(ret.Runtime) != "600160005500" {
+		t.Errorf("unexpected runtime %x", ret.Runtime)
+	}
+	if hex.EncodeToString(ret.ConstructorArgs) != args || ret.ArgsOffset != ret.RuntimeOffset+6 {
+		t.Errorf("unexpected constructor args %x at %d", ret.ConstructorArgs, ret.ArgsOffset)
+	}
+
+	if _, err := ParseInitCode(assemble(t, "PUSH0 PUSH0 RETURN")); err != ErrNoRuntime {
+		t.Errorf("expected ErrNoRuntime, got %v", err)
+	}
+}
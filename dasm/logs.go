@@ -0,0 +1,165 @@
+package dasm
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var ErrUnknownEvent = errors.New("no known event decodes the log")
+
+// DecodedLog is a log decoded against one of the known events.
+type DecodedLog struct {
+	Signature  string        // signature of the event, e.g. `Transfer(address,address,uint256)`
+	Interfaces []string      // names of the interfaces declaring the event
+	Anonymous  bool          // the event is anonymous, it was matched by its arguments only
+	Inputs     abi.Arguments // arguments of the event in declaration order
+	Values     []interface{} // decoded values of the arguments, the hash of the value for indexed dynamic types
+}
+
+// isHashedTopic tells whether the indexed values of the type are stored as their hash.
+func isHashedTopic(typ abi.Type) bool {
+	switch typ.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return true
+	}
+	return false
+}
+
+// isPadded tells whether the word is a validly padded ABI encoding of a value of the
+// static type, e.g. the high 12 bytes of an address are zero and an intN is sign extended.
+func isPadded(typ abi.Type, word []byte) bool {
+	isFilled := func(b []byte, fill byte) bool {
+		for _, c := range b {
+			if c != fill {
+				return false
+			}
+		}
+		return true
+	}
+	switch typ.T {
+	case abi.AddressTy:
+		return isFilled(word[:12], 0)
+	case abi.BoolTy:
+		return isFilled(word[:31], 0) && word[31] <= 1
+	case abi.UintTy:
+		return isFilled(word[:32-typ.Size/8], 0)
+	case abi.IntTy:
+		fill := byte(0)
+		if word[32-typ.Size/8]&0x80 != 0 {
+			fill = 0xff
+		}
+		return isFilled(word[:32-typ.Size/8], fill)
+	case abi.FixedBytesTy:
+		return isFilled(word[typ.Size:], 0)
+	case abi.FunctionTy:
+		return isFilled(word[24:], 0)
+	}
+	return true
+}
+
+// unpackEvent decodes the indexed arguments from the topics and the others from the data.
+func unpackEvent(inputs abi.Arguments, topics []common.Hash, data []byte) ([]interface{}, error) {
+	indexed := 0
+	for _, input := range inputs {
+		if input.Indexed {
+			indexed++
+		}
+	}
+	if indexed != len(topics) {
+		return nil, fmt.Errorf("expected %d indexed topics, got %d", indexed, len(topics))
+	}
+	unindexed, err := inputs.NonIndexed().Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, 0, len(inputs))
+	for _, input := range inputs {
+		if !input.Indexed {
+			values = append(values, unindexed[0])
+			unindexed = unindexed[1:]
+			continue
+		}
+		topic := topics[0]
+		topics = topics[1:]
+		if isHashedTopic(input.Type) {
+			values = append(values, topic)
+			continue
+		}
+		value, err := abi.Arguments{{Type: input.Type}}.Unpack(topic.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value[0])
+	}
+	return values, nil
+}
+
+// DecodeLog decodes a log against the events of the interfaces. The events are looked up
+// by the first topic, if none of them decodes the log the anonymous events are tried in
+// turn, matching the anonymous events whose indexed arguments are validly padded in the
+// topics. All the events which decode the log are returned.
+func DecodeLog(log *types.Log, interfaces []Interface) ([]*DecodedLog, error) {
+	decoded := make([]*DecodedLog, 0)
+	add := func(intf Interface, elem ABIElement, topics []common.Hash) {
+		inputs := abi.Arguments(elem.Inputs)
+		values, err := unpackEvent(inputs, topics, log.Data)
+		if err != nil {
+			return
+		}
+		// Anonymous events are guessed from the number of topics only, a topic which
+		// is not a valid encoding of its argument rules the event out.
+		if elem.Anonymous {
+			i := 0
+			for _, input := range inputs {
+				if !input.Indexed {
+					continue
+				}
+				if !isHashedTopic(input.Type) && !isPadded(input.Type, topics[i].Bytes()) {
+					return
+				}
+				i++
+			}
+		}
+		sig := elem.Identifier()
+		idx := slices.IndexFunc(decoded, func(d *DecodedLog) bool { return d.Signature == sig && d.Anonymous == elem.Anonymous })
+		if idx >= 0 {
+			decoded[idx].Interfaces = append(decoded[idx].Interfaces, intf.Name)
+			return
+		}
+		decoded = append(decoded, &DecodedLog{
+			Signature:  sig,
+			Interfaces: []string{intf.Name},
+			Anonymous:  elem.Anonymous,
+			Inputs:     inputs,
+			Values:     values,
+		})
+	}
+	if len(log.Topics) > 0 {
+		topic0 := common.Bytes2Hex(log.Topics[0].Bytes())
+		for _, intf := range interfaces {
+			if elem, ok := intf.Elements[topic0]; ok && elem.Type == "event" && !elem.Anonymous {
+				add(intf, elem, log.Topics[1:])
+			}
+		}
+	}
+	if len(decoded) == 0 {
+		for _, intf := range interfaces {
+			for _, elem := range intf.Elements {
+				if elem.Type == "event" && elem.Anonymous {
+					add(intf, elem, log.Topics)
+				}
+			}
+		}
+		slices.SortFunc(decoded, func(a, b *DecodedLog) int { return strings.Compare(a.Signature, b.Signature) })
+	}
+	if len(decoded) == 0 {
+		return nil, ErrUnknownEvent
+	}
+	return decoded, nil
+}
@@ -0,0 +1,106 @@
+package dasm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestDecodeLog(t *testing.T) {
+	intf := loadInterface(t, "Token", `[
+		{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256"}]},
+		{"type":"event","name":"Memo","anonymous":true,"inputs":[{"name":"sender","type":"address","indexed":true},{"name":"text","type":"string","indexed":true},{"name":"amount","type":"uint256"}]}
+	]`)
+	from, to := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+	value := common.BigToHash(big.NewInt(42)).Bytes()
+	log := &types.Log{
+		Topics: []common.Hash{crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")), common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:   value,
+	}
+	decoded, err := DecodeLog(log, []Interface{intf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || decoded[0].Signature != "Transfer(address,address,uint256)" || decoded[0].Anonymous ||
+		decoded[0].Values[0].(common.Address) != from || decoded[0].Values[1].(common.Address) != to || decoded[0].Values[2].(*big.Int).Int64() != 42 {
+		t.Errorf("unexpected decoded log %+v", decoded)
+	}
+
+	// Transfer log of The DAO token recorded on mainnet.
+	log = &types.Log{
+		Topics: []common.Hash{
+			common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"),
+			common.HexToHash("0x000000000000000000000000c0ee9db1a9e07ca63e4ff0d5fb6f86bf68d47b89"),
+			common.HexToHash("0x0000000000000000000000004fd27b205895e698fa350f7ea57cec8a21927fcd"),
+		},
+		Data: common.FromHex("0x00000000000000000000000000000000000000000001819451f999d617dafa93"),
+	}
+	decoded, err = DecodeLog(log, []Interface{intf})
+	if err != nil || len(decoded) != 1 || decoded[0].Values[1].(common.Address) != common.HexToAddress("0x4fd27b205895e698fa350f7ea57cec8a21927fcd") ||
+		decoded[0].Values[2].(*big.Int).Cmp(new(big.Int).SetBytes(log.Data)) != 0 {
+		t.Errorf("unexpected decoded recorded log %+v: %v", decoded, err)
+	}
+
+	// Anonymous events have no signature topic, the indexed string is hashed.
+	textHash := crypto.Keccak256Hash([]byte("hello"))
+	log = &types.Log{Topics: []common.Hash{common.BytesToHash(from.Bytes()), textHash}, Data: value}
+	decoded, err = DecodeLog(log, []Interface{intf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || !decoded[0].Anonymous || decoded[0].Values[1].(common.Hash) != textHash {
+		t.Errorf("unexpected decoded anonymous log %+v", decoded)
+	}
+
+	// A hash in place of the address is not a valid encoding of the anonymous event.
+	log = &types.Log{Topics: []common.Hash{textHash, textHash}, Data: value}
+	if _, err := DecodeLog(log, []Interface{intf}); err != ErrUnknownEvent {
+		t.Errorf("expected ErrUnknownEvent for an unpadded address, got %v", err)
+	}
+
+	log = &types.Log{Topics: []common.Hash{crypto.Keccak256Hash([]byte("Unknown()"))}}
+	if _, err := DecodeLog(log, []Interface{intf}); err != ErrUnknownEvent {
+		t.Errorf("expected ErrUnknownEvent, got %v", err)
+	}
+}
+
+func TestIsPadded(t *testing.T) {
+	tests := []struct {
+		typ   string
+		word  string
+		valid bool
+	}{
+		{"address", "0x000000000000000000000000dac17f958d2ee523a2206206994597c13d831ec7", true},
+		{"address", "0x000000000000000000000001dac17f958d2ee523a2206206994597c13d831ec7", false},
+		{"bool", "0x01", true},
+		{"bool", "0x02", false},
+		{"uint8", "0xff", true},
+		{"uint8", "0x0100", false},
+		{"uint24", "0xffffff", true},
+		{"uint24", "0x01000000", false},
+		{"uint256", "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", true},
+		{"int16", "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8000", true},
+		{"int16", "0x7fff", true},
+		{"int16", "0xffff", false},
+		{"int16", "0x00000000000000000000000000000000000000000000000000000000ffff8000", false},
+		{"bytes4", "0xa9059cbb00000000000000000000000000000000000000000000000000000000", true},
+		{"bytes4", "0xa9059cbb01000000000000000000000000000000000000000000000000000000", false},
+	}
+	for _, test := range tests {
+		typ, err := abi.NewType(test.typ, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		word := common.LeftPadBytes(common.FromHex(test.word), 32)
+		if test.typ == "bytes4" {
+			word = common.FromHex(test.word)
+		}
+		if valid := isPadded(typ, word); valid != test.valid {
+			t.Errorf("isPadded(%s, %s) = %v, want %v", test.typ, test.word, valid, test.valid)
+		}
+	}
+}
@@ -59,26 +59,6 @@ func matchPattern(ins []instruction, pattern []matcherFn) bool {
 	return true
 }
 
-func matchFuncSelector(ins []instruction) bool {
-	return matchPattern(ins, []matcherFn{
-		opExact(vm.DUP1),
-		opAnyOf(vm.PUSH3, vm.PUSH4),
-		opAnyOf(vm.GT, vm.EQ),
-		opAnyOf(vm.PUSH2, vm.PUSH3),
-		opExact(vm.JUMPI),
-	})
-}
-
-func matchSplitSelector(ins []instruction) bool {
-	return matchPattern(ins, []matcherFn{
-		opExact(vm.DUP1),
-		opAnyOf(vm.PUSH3, vm.PUSH4),
-		opExact(vm.GT),
-		opAnyOf(vm.PUSH2, vm.PUSH3),
-		opExact(vm.JUMPI),
-	})
-}
-
 func findJumptable(it *instructionIterator) bool {
 	pattern := []matcherFn{
 		opExact(vm.PUSH1),
@@ -0,0 +1,295 @@
+package dasm
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const cborMaxDepth = 16
+
+var ErrNoMetadata = errors.New("no metadata found")
+
+// Metadata is the CBOR encoded metadata appended to the runtime code by solc and vyper,
+// its length is stored in the last 2 bytes of the bytecode.
+type Metadata struct {
+	Compiler     Compiler
+	Version      string                 // compiler version, e.g. "0.8.19"
+	IPFS         string                 // base58 encoded IPFS CID of the metadata file
+	Swarm        string                 // hex encoded swarm hash of the metadata file, bzzr0 or bzzr1
+	Experimental bool                   // compiled with experimental features enabled
+	Fields       map[string]interface{} // all decoded fields of the metadata map
+	Offset       int                    // offset of the metadata in the bytecode
+	Raw          []byte                 // CBOR encoded metadata including the length suffix
+}
+
+func (m *Metadata) String() string {
+	parts := []string{string(m.Compiler)}
+	if m.Version != "" {
+		parts[0] += " " + m.Version
+	}
+	if m.IPFS != "" {
+		parts = append(parts, "ipfs "+m.IPFS)
+	}
+	if m.Swarm != "" {
+		parts = append(parts, "bzzr "+m.Swarm)
+	}
+	if m.Experimental {
+		parts = append(parts, "experimental")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// cborDecoder decodes the subset of CBOR used by compiler metadata: integers, byte and
+// text strings, arrays, maps with text keys and simple values.
+type cborDecoder struct {
+	b   []byte
+	pos int
+}
+
+func (d *cborDecoder) header() (major byte, arg uint64, err error) {
+	if d.pos >= len(d.b) {
+		return 0, 0, errors.New("cbor: unexpected end of data")
+	}
+	ib := d.b[d.pos]
+	d.pos++
+	major, info := ib>>5, ib&0x1f
+	if info < 24 {
+		return major, uint64(info), nil
+	}
+	if info > 27 {
+		return 0, 0, fmt.Errorf("cbor: unsupported additional info %d", info)
+	}
+	size := 1 << (info - 24)
+	if d.pos+size > len(d.b) {
+		return 0, 0, errors.New("cbor: unexpected end of data")
+	}
+	buf := make([]byte, 8)
+	copy(buf[8-size:], d.b[d.pos:d.pos+size])
+	d.pos += size
+	return major, binary.BigEndian.Uint64(buf), nil
+}
+
+func (d *cborDecoder) decode(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, errors.New("cbor: nesting too deep")
+	}
+	major, arg, err := d.header()
+	if err != nil {
+		return nil, err
+	}
+	remaining := uint64(len(d.b) - d.pos)
+	switch major {
+	case 0:
+		return arg, nil
+	case 1:
+		return -1 - int64(arg), nil
+	case 2, 3:
+		if arg > remaining {
+			return nil, errors.New("cbor: unexpected end of data")
+		}
+		val := d.b[d.pos : d.pos+int(arg)]
+		d.pos += int(arg)
+		if major == 3 {
+			return string(val), nil
+		}
+		return val, nil
+	case 4:
+		if arg > remaining {
+			return nil, errors.New("cbor: unexpected end of data")
+		}
+		list := make([]interface{}, arg)
+		for i := range list {
+			if list[i], err = d.decode(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case 5:
+		if arg > remaining {
+			return nil, errors.New("cbor: unexpected end of data")
+		}
+		fields := make(map[string]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			key, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("cbor: unsupported map key %v", key)
+			}
+			if fields[name], err = d.decode(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return fields, nil
+	case 7:
+		switch arg {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22:
+			return nil, nil
+		}
+	}
+	return nil, fmt.Errorf("cbor: unsupported major type %d", major)
+}
+
+// decodeCBOR decodes a single CBOR item spanning the whole input.
+func decodeCBOR(data []byte) (interface{}, error) {
+	d := &cborDecoder{b: data}
+	val, err := d.decode(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(data) {
+		return nil, fmt.Errorf("cbor: %d trailing bytes", len(data)-d.pos)
+	}
+	return val, nil
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Encode encodes the data with the bitcoin alphabet used by IPFS CIDv0.
+func base58Encode(data []byte) string {
+	digits := make([]byte, 0, len(data)*138/100+1)
+	for _, b := range data {
+		carry := int(b)
+		for i := range digits {
+			carry += int(digits[i]) << 8
+			digits[i] = byte(carry % 58)
+			carry /= 58
+		}
+		for carry > 0 {
+			digits = append(digits, byte(carry%58))
+			carry /= 58
+		}
+	}
+	var sb strings.Builder
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		sb.WriteByte(base58Alphabet[0])
+	}
+	for i := len(digits) - 1; i >= 0; i-- {
+		sb.WriteByte(base58Alphabet[digits[i]])
+	}
+	return sb.String()
+}
+
+// versionOf formats the version of the metadata, solc stores releases as 3 bytes and
+// pre-releases as text, vyper stores a list of numbers.
+func versionOf(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case []byte:
+		if len(v) == 3 {
+			return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+		}
+		return hex.EncodeToString(v)
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ".")
+	}
+	return ""
+}
+
+// newMetadata fills the metadata from its decoded fields, returns false if none of the
+// known keys is found.
+func newMetadata(item interface{}) (*Metadata, bool) {
+	switch v := item.(type) {
+	case []interface{}:
+		// vyper since 0.3.10 appends a list of code layout info followed by the version map.
+		for _, elem := range v {
+			if fields, ok := elem.(map[string]interface{}); ok && fields["vyper"] != nil {
+				return newMetadata(fields)
+			}
+		}
+	case map[string]interface{}:
+		m := &Metadata{Compiler: CompilerUnknown, Fields: v}
+		known := false
+		for key, val := range v {
+			switch key {
+			case "solc":
+				m.Compiler, m.Version = CompilerSolc, versionOf(val)
+			case "vyper":
+				m.Compiler, m.Version = CompilerVyper, versionOf(val)
+			case "ipfs":
+				if hash, ok := val.([]byte); ok {
+					m.IPFS = base58Encode(hash)
+				}
+			case "bzzr0", "bzzr1":
+				if hash, ok := val.([]byte); ok {
+					m.Swarm = hex.EncodeToString(hash)
+				}
+			case "experimental":
+				m.Experimental, _ = val.(bool)
+			default:
+				continue
+			}
+			known = true
+		}
+		if m.Compiler == CompilerUnknown && (m.IPFS != "" || m.Swarm != "") {
+			// solc before 0.5.9 only stores the swarm hash.
+			m.Compiler = CompilerSolc
+		}
+		return m, known
+	}
+	return nil, false
+}
+
+// decodeMetadataAt decodes the metadata starting at offset and ending before the 2-byte
+// length suffix.
+func decodeMetadataAt(bytecode []byte, offset int) (*Metadata, bool) {
+	if offset < 0 || offset >= len(bytecode)-2 {
+		return nil, false
+	}
+	item, err := decodeCBOR(bytecode[offset : len(bytecode)-2])
+	if err != nil {
+		return nil, false
+	}
+	m, ok := newMetadata(item)
+	if !ok {
+		return nil, false
+	}
+	m.Offset = offset
+	m.Raw = bytecode[offset:]
+	return m, true
+}
+
+// ParseMetadata locates the CBOR metadata at the end of the bytecode using its 2-byte
+// length suffix and decodes it. The length excludes the suffix itself, except for vyper
+// since 0.3.10 which counts it.
+func ParseMetadata(bytecode []byte) (*Metadata, error) {
+	if len(bytecode) < 2 || IsEOF(bytecode) {
+		return nil, ErrNoMetadata
+	}
+	length := int(binary.BigEndian.Uint16(bytecode[len(bytecode)-2:]))
+	if length == 0 {
+		return nil, ErrNoMetadata
+	}
+	if m, ok := decodeMetadataAt(bytecode, len(bytecode)-2-length); ok {
+		return m, nil
+	}
+	if m, ok := decodeMetadataAt(bytecode, len(bytecode)-length); ok {
+		return m, nil
+	}
+	return nil, ErrNoMetadata
+}
+
+// StripMetadata returns the bytecode without its trailing metadata.
+func StripMetadata(bytecode []byte) []byte {
+	if m, err := ParseMetadata(bytecode); err == nil {
+		return bytecode[:m.Offset]
+	}
+	return bytecode
+}
@@ -0,0 +1,64 @@
+package dasm
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestParseMetadata(t *testing.T) {
+	solcMetadata := "a2" + "6469706673" + "5822" + "1220" + strings.Repeat("11", 32) + "64736f6c6343" + "000813" + "0033"
+	vyperMetadata := "a165767970657283000301" + "000b"
+	// Layouts of vyper since 0.3.10 as emitted by vyper/ir/compile_ir.py: a CBOR array
+	// of the runtime size, the data section lengths, the immutables size and the version map
+	// (preceded by the integrity hash since 0.4.0), the length counts its 2 bytes.
+	vyper0310Metadata := "84" + "1903e8" + "80" + "00" + "a1" + "6576797065728300030a" + "0013"
+	vyper040Metadata := "85" + "5820" + strings.Repeat("22", 32) + "1903e8" + "80" + "00" + "a1" + "65767970657283000400" + "0035"
+	tests := []struct {
+		name     string
+		code     string
+		compiler Compiler
+		version  string
+		ipfs     string
+	}{
+		{"solc", "0x6080604052600080fd" + "fe" + solcMetadata, CompilerSolc, "0.8.19", "QmPVGjYFugq4XUyBfoTHG6c3qxfBS26jEdaFM1gdAVuMZ2"},
+		{"vyper", "0x600080fd" + vyperMetadata, CompilerVyper, "0.3.1", ""},
+		{"vyper 0.3.10", "0x5f5ffd" + vyper0310Metadata, CompilerVyper, "0.3.10", ""},
+		{"vyper 0.4.0", "0x5f5ffd" + vyper040Metadata, CompilerVyper, "0.4.0", ""},
+	}
+	for _, tt := range tests {
+		code := hexutil.MustDecode(tt.code)
+		m, err := ParseMetadata(code)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if m.Compiler != tt.compiler || m.Version != tt.version || m.IPFS != tt.ipfs {
+			t.Errorf("%s: unexpected metadata %s", tt.name, m)
+		}
+		if DetectCompiler(code) != tt.compiler {
+			t.Errorf("%s: detected compiler %s", tt.name, DetectCompiler(code))
+		}
+		stripped := StripMetadata(code)
+		if len(stripped) != m.Offset || len(m.Raw) != len(code)-m.Offset {
+			t.Errorf("%s: metadata offset %d, stripped %d bytes", tt.name, m.Offset, len(stripped))
+		}
+		lines, err := Disassemble(code)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		listing := strings.Join(lines, "")
+		if !strings.Contains(listing, ".metadata") || strings.Contains(listing, "PUSH32") {
+			t.Errorf("%s: metadata is disassembled:\n%s", tt.name, listing)
+		}
+	}
+
+	info := Fingerprint(hexutil.MustDecode("0x5f5ffd" + vyper0310Metadata))
+	if info.Version != "0.3.10" || !slices.Contains(info.Evidence, "code layout in metadata") {
+		t.Errorf("unexpected vyper 0.3.10 fingerprint %+v", info)
+	}
+	if _, err := ParseMetadata(hexutil.MustDecode("0x6080604052600080fd")); err != ErrNoMetadata {
+		t.Errorf("expected no metadata, got %v", err)
+	}
+}
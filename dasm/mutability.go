@@ -0,0 +1,92 @@
+package dasm
+
+import (
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// State mutabilities of the functions, as named in the ABI JSON.
+const (
+	MutabilityPure       = "pure"
+	MutabilityView       = "view"
+	MutabilityNonPayable = "nonpayable"
+	MutabilityPayable    = "payable"
+)
+
+// mutabilityHooks records the CALLVALUE checks and the instructions reading or writing
+// the state in a function body.
+type mutabilityHooks struct {
+	baseHooks
+	valueCheck bool
+	writes     bool
+	reads      bool
+}
+
+func (h *mutabilityHooks) step(st *emuState, in instruction, args []symValue) {
+	switch in.op {
+	case vm.SSTORE, vm.TSTORE, vm.LOG0, vm.LOG1, vm.LOG2, vm.LOG3, vm.LOG4,
+		vm.CREATE, vm.CREATE2, vm.SELFDESTRUCT, vm.CALLCODE, vm.DELEGATECALL:
+		h.writes = true
+	case vm.CALL:
+		// View functions call other contracts with STATICCALL, older compilers call the
+		// precompiles with a CALL sending no value.
+		if value := args[2]; !value.isConst() || !value.konst.IsZero() {
+			h.writes = true
+		}
+	case vm.SLOAD, vm.TLOAD, vm.STATICCALL, vm.ADDRESS, vm.BALANCE, vm.SELFBALANCE, vm.ORIGIN, vm.CALLER,
+		vm.GASPRICE, vm.EXTCODESIZE, vm.EXTCODECOPY, vm.EXTCODEHASH, vm.BLOCKHASH, vm.BLOBHASH,
+		vm.COINBASE, vm.TIMESTAMP, vm.NUMBER, vm.DIFFICULTY, vm.GASLIMIT, vm.CHAINID, vm.BASEFEE,
+		vm.BLOBBASEFEE:
+		h.reads = true
+	}
+}
+
+func (h *mutabilityHooks) result(st *emuState, in instruction, args []symValue, res symValue) symValue {
+	switch {
+	case in.op == vm.CALLVALUE:
+		return taggedValue(tagCallValue, nil)
+	case in.op == vm.ISZERO && args[0].tag == tagCallValue:
+		return taggedValue(tagCallValueZero, nil)
+	}
+	return res
+}
+
+func (h *mutabilityHooks) jumpi(st *emuState, in instruction, dest, cond symValue) (bool, bool) {
+	if cond.tag == tagCallValue || cond.tag == tagCallValueZero {
+		h.valueCheck = true
+	}
+	return true, true
+}
+
+// checksValueFirst tells whether the code rejects any value before the dispatcher reads
+// the selector, solc does so when none of the functions is payable.
+func checksValueFirst(bytecode []byte) bool {
+	it := NewInstructionIterator(StripMetadata(bytecode))
+	for it.Next() {
+		switch it.Instruction().op {
+		case vm.CALLVALUE:
+			return true
+		case vm.CALLDATALOAD, vm.JUMP, vm.STOP, vm.RETURN, vm.REVERT:
+			return false
+		}
+	}
+	return false
+}
+
+// InferStateMutability guesses the state mutability of the function whose body starts
+// at the entry. Functions are payable unless a CALLVALUE check guards them, nonpayable if
+// the state can be written on any path, view if it can be read and pure otherwise.
+func InferStateMutability(bytecode []byte, entry uint64) string {
+	hooks := &mutabilityHooks{valueCheck: checksValueFirst(bytecode)}
+	emu := newEmulator(bytecode, hooks)
+	emu.maxSteps = bodyMaxSteps
+	emu.run(newEmuState(entry))
+	switch {
+	case !hooks.valueCheck:
+		return MutabilityPayable
+	case hooks.writes:
+		return MutabilityNonPayable
+	case hooks.reads:
+		return MutabilityView
+	}
+	return MutabilityPure
+}
@@ -0,0 +1,80 @@
+package dasm
+
+import (
+	"testing"
+)
+
+func TestInferStateMutability(t *testing.T) {
+	code := assemble(t, `
+		PUSH0 CALLDATALOAD PUSH1 0xe0 SHR
+		DUP1 PUSH4 0x00000001 EQ PUSH2 @deposit JUMPI
+		DUP1 PUSH4 0x00000002 EQ PUSH2 @store JUMPI
+		DUP1 PUSH4 0x00000003 EQ PUSH2 @get JUMPI
+		DUP1 PUSH4 0x00000004 EQ PUSH2 @add JUMPI
+		DUP1 PUSH4 0x00000005 EQ PUSH2 @hash JUMPI
+		DUP1 PUSH4 0x00000006 EQ PUSH2 @pay JUMPI
+		PUSH0 DUP1 REVERT
+		deposit: JUMPDEST CALLVALUE PUSH0 SSTORE STOP
+		store: JUMPDEST CALLVALUE DUP1 ISZERO PUSH2 @store_ok JUMPI PUSH0 DUP1 REVERT
+		store_ok: JUMPDEST POP PUSH1 0x04 CALLDATALOAD PUSH0 SSTORE STOP
+		get: JUMPDEST CALLVALUE PUSH2 @revert JUMPI PUSH0 SLOAD PUSH0 MSTORE PUSH1 0x20 PUSH0 RETURN
+		add: JUMPDEST CALLVALUE PUSH2 @revert JUMPI PUSH1 0x24 CALLDATALOAD PUSH1 0x04 CALLDATALOAD ADD PUSH0 MSTORE PUSH1 0x20 PUSH0 RETURN
+		hash: JUMPDEST CALLVALUE PUSH2 @revert JUMPI PUSH1 0x20 PUSH0 PUSH1 0x20 PUSH0 PUSH0 PUSH1 0x02 GAS CALL STOP
+		pay: JUMPDEST CALLVALUE PUSH2 @revert JUMPI PUSH0 PUSH0 PUSH0 PUSH0 PUSH1 0x04 CALLDATALOAD CALLER GAS CALL STOP
+		revert: JUMPDEST PUSH0 DUP1 REVERT`)
+	expected := map[string]string{
+		"00000001": MutabilityPayable,
+		"00000002": MutabilityNonPayable,
+		"00000003": MutabilityView,
+		"00000004": MutabilityPure,
+		"00000005": MutabilityPure,       // calls the sha256 precompile without value
+		"00000006": MutabilityNonPayable, // sends the value of an argument
+	}
+	for _, sel := range EmulateDispatcher(code).Selectors {
+		if mutability := InferStateMutability(code, sel.Entry); mutability != expected[sel.ID] {
+			t.Errorf("unexpected state mutability of %s: %s", sel.ID, mutability)
+		}
+	}
+
+	// The value is checked before the dispatcher when none of the functions is payable.
+	code = assemble(t, `
+		CALLVALUE DUP1 ISZERO PUSH2 @ok JUMPI PUSH0 DUP1 REVERT
+		ok: JUMPDEST POP
+		PUSH0 CALLDATALOAD PUSH1 0xe0 SHR
+		DUP1 PUSH4 0x00000001 EQ PUSH2 @get JUMPI
+		PUSH0 DUP1 REVERT
+		get: JUMPDEST PUSH0 SLOAD PUSH0 MSTORE PUSH1 0x20 PUSH0 RETURN`)
+	if mutability := InferStateMutability(code, EmulateDispatcher(code).Selectors[0].Entry); mutability != MutabilityView {
+		t.Errorf("unexpected state mutability %s", mutability)
+	}
+}
+
+func TestInferStateMutabilityCompiled(t *testing.T) {
+	expected := map[string]map[string]string{
+		"solc-0.8.7-storage.hex": {"2e64cec1": MutabilityView, "6057361d": MutabilityNonPayable},
+		// transfer only emits an event, which modifies the state.
+		"solc-0.8.18-token.hex": {"a9059cbb": MutabilityNonPayable},
+		"solc-0.6.6-ballot.hex": {
+			"0121b93f": MutabilityNonPayable, // vote(uint256)
+			"013cf08b": MutabilityView,       // proposals(uint256)
+			"2e4176cf": MutabilityView,       // chairperson()
+			"5c19a95c": MutabilityNonPayable, // delegate(address)
+			"609ff1bd": MutabilityView,       // winningProposal()
+			"9e7b8d61": MutabilityNonPayable, // giveRightToVote(address)
+			"a3ec138d": MutabilityView,       // voters(address)
+			"e2ba53f0": MutabilityView,       // winnerName()
+		},
+	}
+	for name, mutabilities := range expected {
+		code := loadFixture(t, name)
+		selectors := ParseDispatcher(code).Selectors
+		if len(selectors) != len(mutabilities) {
+			t.Fatalf("%s: unexpected selectors %v", name, selectors)
+		}
+		for _, sel := range selectors {
+			if mutability := InferStateMutability(code, sel.Entry); mutability != mutabilities[sel.ID] {
+				t.Errorf("%s: unexpected state mutability of %s: %s", name, sel.ID, mutability)
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package dasm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultOpenchainURL = "https://api.openchain.xyz/signature-database/v1/lookup"
+
+// OpenchainSource resolves the selectors and the topics with the openchain.xyz signature
+// database API, the signatures looked up are cached.
+type OpenchainSource struct {
+	URL    string // lookup endpoint of the API
+	Client *http.Client
+
+	mu    sync.Mutex
+	cache map[string][]string // signatures by kind and "0x" prefixed id, e.g. "function:0xa9059cbb"
+}
+
+func NewOpenchainSource() *OpenchainSource {
+	return &OpenchainSource{
+		URL:    defaultOpenchainURL,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// openchainResults holds the signatures of the ids looked up, by kind ("function" or
+// "event") and by hex id.
+type openchainResults map[string]map[string][]struct {
+	Name string `json:"name"`
+}
+
+type openchainResponse struct {
+	Ok     bool             `json:"ok"`
+	Error  string           `json:"error"`
+	Result openchainResults `json:"result"`
+}
+
+// lookup fetches the signatures of the function selectors and event topics not cached
+// yet in a single request, and caches them, including the ids without signature.
+func (s *OpenchainSource) lookup(ctx context.Context, functions []string, events []string) error {
+	query := url.Values{"filter": {"true"}}
+	ids := map[string][]string{"function": functions, "event": events}
+	s.mu.Lock()
+	if s.cache == nil {
+		s.cache = make(map[string][]string)
+	}
+	for kind, kindIDs := range ids {
+		uncached := make([]string, 0, len(kindIDs))
+		for _, id := range kindIDs {
+			if _, ok := s.cache[kind+":0x"+id]; !ok {
+				uncached = append(uncached, "0x"+id)
+			}
+		}
+		ids[kind] = uncached
+		if len(uncached) > 0 {
+			query.Set(kind, strings.Join(uncached, ","))
+		}
+	}
+	s.mu.Unlock()
+	if len(ids["function"]) == 0 && len(ids["event"]) == 0 {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("signature lookup failed: %s", resp.Status)
+	}
+	var result openchainResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("could not decode signature lookup response: %w", err)
+	}
+	if !result.Ok {
+		return fmt.Errorf("signature lookup failed: %s", result.Error)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for kind, kindIDs := range ids {
+		for _, id := range kindIDs {
+			sigs := make([]string, 0)
+			for _, item := range result.Result[kind][id] {
+				sigs = append(sigs, item.Name)
+			}
+			s.cache[kind+":"+id] = sigs
+		}
+	}
+	return nil
+}
+
+// signatures returns the signatures of a function selector or an event topic, kind is
+// "function" or "event".
+func (s *OpenchainSource) signatures(ctx context.Context, kind string, id string) ([]string, error) {
+	ids := map[string][]string{kind: {id}}
+	if err := s.lookup(ctx, ids["function"], ids["event"]); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache[kind+":0x"+id], nil
+}
+
+func (s *OpenchainSource) FunctionSignatures(ctx context.Context, selector string) ([]string, error) {
+	return s.signatures(ctx, "function", selector)
+}
+
+func (s *OpenchainSource) EventSignatures(ctx context.Context, topic string) ([]string, error) {
+	return s.signatures(ctx, "event", topic)
+}
+
+// PrefetchSignatures looks up the function selectors and event topics in one request.
+func (s *OpenchainSource) PrefetchSignatures(ctx context.Context, functions []string, events []string) error {
+	return s.lookup(ctx, functions, events)
+}
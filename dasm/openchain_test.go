@@ -0,0 +1,67 @@
+package dasm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenchainSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("event") != "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef" {
+			w.Write([]byte(`{"ok":true,"result":{"event":{},"function":{}}}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":{"event":{"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef":[{"name":"Transfer(address,address,uint256)","filtered":false}]},"function":{}}}`))
+	}))
+	defer server.Close()
+
+	source := NewOpenchainSource()
+	source.URL = server.URL
+	sigs, err := source.EventSignatures(context.Background(), "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != 1 || sigs[0] != "Transfer(address,address,uint256)" {
+		t.Errorf("unexpected event signatures %v", sigs)
+	}
+	if sigs, err := source.FunctionSignatures(context.Background(), "deadbeef"); err != nil || len(sigs) != 0 {
+		t.Errorf("unexpected function signatures %v: %v", sigs, err)
+	}
+}
+
+func TestOpenchainSourcePrefetch(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("function") != "0x313ce567,0xdeadbeef" {
+			t.Errorf("unexpected functions looked up %q", r.URL.Query().Get("function"))
+		}
+		w.Write([]byte(`{"ok":true,"result":{"event":{"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef":[{"name":"Transfer(address,address,uint256)","filtered":false}]},"function":{"0x313ce567":[{"name":"decimals()","filtered":false}],"0xdeadbeef":null}}}`))
+	}))
+	defer server.Close()
+
+	code := assemble(t, `
+		PUSH0 CALLDATALOAD PUSH1 0xe0 SHR
+		DUP1 PUSH4 0x313ce567 EQ PUSH2 @decimals JUMPI
+		DUP1 PUSH4 0xdeadbeef EQ PUSH2 @decimals JUMPI
+		PUSH0 DUP1 REVERT
+		decimals: JUMPDEST
+		PUSH32 0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef PUSH0 DUP1 LOG1 STOP`)
+	source := NewOpenchainSource()
+	source.URL = server.URL
+	report, err := NewAnalyzer(AnalyzerConfig{Signatures: []SignatureSource{source}}).AnalyzeBytecode(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Errorf("expected a single lookup, got %d", requests)
+	}
+	if len(report.Selectors) != 2 || len(report.Selectors[0].Signatures) != 1 || len(report.Selectors[1].Signatures) != 0 {
+		t.Errorf("unexpected selectors %+v", report.Selectors)
+	}
+	if len(report.Events) != 1 || len(report.Events[0].Signatures) != 1 {
+		t.Errorf("unexpected events %+v", report.Events)
+	}
+}
@@ -10,7 +10,6 @@ import (
 
 func ParseEventTopics(bytecode []byte) []string {
 	topics := make(map[string]bool)
-	it := NewInstructionIterator(bytecode)
 	findPush32 := func(ins []instruction, maxBackward int) *instruction {
 		maxLoop := minInt(len(ins), maxBackward)
 		for i := 1; i <= maxLoop; i++ {
@@ -22,12 +21,14 @@ func ParseEventTopics(bytecode []byte) []string {
 		return nil
 	}
 	matchFn := opAnyOf(vm.LOG0, vm.LOG1, vm.LOG2, vm.LOG3, vm.LOG4)
-	for it.Next() {
-		if matchFn(it.Instruction()) {
-			push32Ins := findPush32(it.ins, 50)
-			if push32Ins != nil {
-				topic := hex.EncodeToString(common.BytesToHash(push32Ins.arg).Bytes())
-				topics[topic] = true
+	for _, it := range codeIterators(bytecode) {
+		for it.Next() {
+			if matchFn(it.Instruction()) {
+				push32Ins := findPush32(it.ins, 50)
+				if push32Ins != nil {
+					topic := hex.EncodeToString(common.BytesToHash(push32Ins.arg).Bytes())
+					topics[topic] = true
+				}
 			}
 		}
 	}
@@ -35,21 +36,10 @@ func ParseEventTopics(bytecode []byte) []string {
 }
 
 func ParseFunctionSelectors(bytecode []byte) []string {
-	methodSigs := make(map[string]bool)
-	it := NewInstructionIterator(bytecode)
-	for it.Next() {
-		ins := it.Instructions(5)
-		if matchFuncSelector(ins) {
-			buf := make([]byte, 4)
-			if ins[1].op == vm.PUSH3 {
-				copy(buf[1:], ins[1].arg)
-			} else if ins[1].op == vm.PUSH4 {
-				copy(buf, ins[1].arg)
-			}
-			methodSigs[hex.EncodeToString(buf)] = true
-		}
+	if IsEOF(bytecode) {
+		return parseEOFDispatcher(bytecode).IDs()
 	}
-	return maps.Keys(methodSigs)
+	return ParseDispatcher(bytecode).IDs()
 }
 
 func GetMethodSigsByID(methodID string, interfaces []Interface) []string {
@@ -61,3 +51,15 @@ func GetMethodSigsByID(methodID string, interfaces []Interface) []string {
 	}
 	return maps.Keys(ret)
 }
+
+// GetErrorSigsByID returns the signatures of the custom errors of the interfaces
+// matching the 4-bytes selector.
+func GetErrorSigsByID(errorID string, interfaces []Interface) []string {
+	ret := make(map[string]bool, 0)
+	for _, intf := range interfaces {
+		if elem, ok := intf.ErrorElements[errorID]; ok {
+			ret[elem.Identifier()] = true
+		}
+	}
+	return maps.Keys(ret)
+}
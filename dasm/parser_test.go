@@ -0,0 +1,344 @@
+package dasm
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// assemble builds bytecode from a whitespace separated list of mnemonics. A token
+// ending with a colon defines a label at the current position, `@label` pushes the
+// label position as the argument of the preceding PUSH instruction or emits it as a
+// 2-bytes value otherwise, `#hex` emits raw data bytes.
+func assemble(t *testing.T, src string) []byte {
+	t.Helper()
+	labels := make(map[string]int)
+	type fixup struct{ pos, size int }
+	fixups := make(map[string][]fixup)
+	code := make([]byte, 0)
+	tokens := strings.Fields(src)
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if strings.HasSuffix(tok, ":") {
+			labels[strings.TrimSuffix(tok, ":")] = len(code)
+			continue
+		}
+		if strings.HasPrefix(tok, "#") {
+			data, err := hex.DecodeString(tok[1:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			code = append(code, data...)
+			continue
+		}
+		if strings.HasPrefix(tok, "@") {
+			fixups[tok[1:]] = append(fixups[tok[1:]], fixup{len(code), 2})
+			code = append(code, 0, 0)
+			continue
+		}
+		op := vm.StringToOp(tok)
+		if op == vm.STOP && tok != "STOP" {
+			t.Fatalf("unknown mnemonic %s", tok)
+		}
+		code = append(code, byte(op))
+		if !op.IsPush() || op == vm.PUSH0 {
+			continue
+		}
+		size := int(op - vm.PUSH0)
+		i++
+		arg := tokens[i]
+		if strings.HasPrefix(arg, "@") {
+			fixups[arg[1:]] = append(fixups[arg[1:]], fixup{len(code), size})
+			code = append(code, make([]byte, size)...)
+			continue
+		}
+		val, ok := new(big.Int).SetString(strings.TrimPrefix(arg, "0x"), 16)
+		if !ok {
+			t.Fatalf("invalid push argument %s", arg)
+		}
+		code = append(code, val.FillBytes(make([]byte, size))...)
+	}
+	for label, list := range fixups {
+		pos, ok := labels[label]
+		if !ok {
+			t.Fatalf("undefined label %s", label)
+		}
+		for _, f := range list {
+			buf := binary.BigEndian.AppendUint64(nil, uint64(pos))
+			copy(code[f.pos:f.pos+f.size], buf[8-f.size:])
+		}
+	}
+	return code
+}
+
+func sorted(list []string) []string {
+	ret := append([]string{}, list...)
+	sort.Strings(ret)
+	return ret
+}
+
+func assertSelectors(t *testing.T, have []string, want ...string) {
+	t.Helper()
+	if fmt.Sprint(sorted(have)) != fmt.Sprint(sorted(want)) {
+		t.Fatalf("selectors mismatch:\nhave %v\nwant %v", sorted(have), sorted(want))
+	}
+}
+
+// splitDispatcher mirrors the dispatcher solc emits for an ERC20 token, where the
+// selectors are split in two halves by a `GT` pivot.
+const splitDispatcher = `
+	PUSH1 0x80 PUSH1 0x40 MSTORE
+	CALLVALUE DUP1 ISZERO PUSH2 @l1 JUMPI PUSH0 DUP1 REVERT
+	l1: JUMPDEST POP
+	PUSH1 0x04 CALLDATASIZE LT PUSH2 @fallback JUMPI
+	PUSH0 CALLDATALOAD PUSH1 0xe0 SHR
+	DUP1 PUSH4 0x70a08231 GT PUSH2 @upper JUMPI
+	DUP1 PUSH4 0x095ea7b3 EQ PUSH2 @f1 JUMPI
+	DUP1 PUSH4 0x18160ddd EQ PUSH2 @f2 JUMPI
+	DUP1 PUSH4 0x23b872dd EQ PUSH2 @f3 JUMPI
+	DUP1 PUSH4 0x70a08231 EQ PUSH2 @f4 JUMPI
+	PUSH2 @fallback JUMP
+	upper: JUMPDEST
+	DUP1 PUSH4 0x95d89b41 EQ PUSH2 @f5 JUMPI
+	DUP1 PUSH4 0xa9059cbb EQ PUSH2 @f6 JUMPI
+	DUP1 PUSH4 0xdd62ed3e EQ PUSH2 @f7 JUMPI
+	fallback: JUMPDEST PUSH0 DUP1 REVERT
+	f1: JUMPDEST STOP
+	f2: JUMPDEST STOP
+	f3: JUMPDEST STOP
+	f4: JUMPDEST STOP
+	f5: JUMPDEST STOP
+	f6: JUMPDEST STOP
+	f7: JUMPDEST STOP
+`
+
+func TestParseSplitDispatcher(t *testing.T) {
+	code := assemble(t, splitDispatcher)
+	dispatcher := ParseDispatcher(code)
+	assertSelectors(t, dispatcher.IDs(),
+		"095ea7b3", "18160ddd", "23b872dd", "70a08231", "95d89b41", "a9059cbb", "dd62ed3e")
+	if len(dispatcher.Pivots) != 1 || dispatcher.Pivots[0] != "70a08231" {
+		t.Fatalf("unexpected pivots %v", dispatcher.Pivots)
+	}
+	for _, sel := range dispatcher.Selectors {
+		if code[sel.Entry] != byte(vm.JUMPDEST) {
+			t.Fatalf("selector %s entry %#x is not a jump destination", sel.ID, sel.Entry)
+		}
+	}
+}
+
+func TestParseSplitDispatcherPivotOnly(t *testing.T) {
+	// The pivot value is not compared for equality anywhere, it must not be reported.
+	code := assemble(t, `
+		PUSH1 0x00 CALLDATALOAD PUSH1 0xe0 SHR
+		DUP1 PUSH4 0x50000000 GT PUSH2 @upper JUMPI
+		DUP1 PUSH4 0x06fdde03 EQ PUSH2 @f1 JUMPI
+		PUSH2 @fallback JUMP
+		upper: JUMPDEST
+		DUP1 PUSH4 0x95d89b41 EQ PUSH2 @f2 JUMPI
+		fallback: JUMPDEST PUSH1 0x00 DUP1 REVERT
+		f1: JUMPDEST STOP
+		f2: JUMPDEST STOP
+	`)
+	assertSelectors(t, ParseFunctionSelectors(code), "06fdde03", "95d89b41")
+}
+
+func TestParseLeadingZeroSelectors(t *testing.T) {
+	// Gas-optimized vanity selectors are pushed with the narrowest PUSH by solc,
+	// the comparison against an enum value after the dispatcher must not be picked up.
+	code := assemble(t, `
+		PUSH1 0x80 PUSH1 0x40 MSTORE
+		PUSH1 0x04 CALLDATASIZE LT PUSH2 @fallback JUMPI
+		PUSH0 CALLDATALOAD PUSH1 0xe0 SHR
+		DUP1 ISZERO PUSH2 @f0 JUMPI
+		DUP1 PUSH1 0x01 EQ PUSH2 @f1 JUMPI
+		DUP1 PUSH2 0xabcd EQ PUSH2 @f2 JUMPI
+		DUP1 PUSH3 0x12abcd EQ PUSH2 @f3 JUMPI
+		DUP1 PUSH4 0x70a08231 EQ PUSH2 @f4 JUMPI
+		fallback: JUMPDEST PUSH0 DUP1 REVERT
+		f0: JUMPDEST STOP
+		f1: JUMPDEST STOP
+		f2: JUMPDEST STOP
+		f3: JUMPDEST STOP
+		f4: JUMPDEST PUSH1 0x04 CALLDATALOAD
+		DUP1 PUSH1 0x02 EQ PUSH2 @enum JUMPI
+		STOP
+		enum: JUMPDEST STOP
+	`)
+	assertSelectors(t, ParseFunctionSelectors(code), "00000000", "00000001", "0000abcd", "0012abcd", "70a08231")
+
+	// deploy(bytes) of a contract compiled with solc 0.8.7 is pushed with PUSH3 0x774360.
+	assertSelectors(t, ParseFunctionSelectors(loadFixture(t, "solc-0.8.7-factory.hex")), "00774360")
+}
+
+func TestParseNonPayableFallback(t *testing.T) {
+	// The CALLVALUE check of a non-payable fallback ends the dispatcher chain with the
+	// same DUP1 ISZERO JUMPI shape as the all-zero selector.
+	code := assemble(t, `
+		PUSH1 0x80 PUSH1 0x40 MSTORE
+		PUSH1 0x04 CALLDATASIZE LT PUSH2 @fallback JUMPI
+		PUSH0 CALLDATALOAD PUSH1 0xe0 SHR
+		DUP1 PUSH4 0x70a08231 EQ PUSH2 @f1 JUMPI
+		fallback: JUMPDEST CALLVALUE DUP1 ISZERO PUSH2 @nonpayable JUMPI PUSH0 DUP1 REVERT
+		nonpayable: JUMPDEST POP STOP
+		f1: JUMPDEST STOP
+	`)
+	assertSelectors(t, ParseFunctionSelectors(code), "70a08231")
+}
+
+func TestParsePush0Selector(t *testing.T) {
+	code := assemble(t, `
+		PUSH0 CALLDATALOAD PUSH1 0xe0 SHR
+		DUP1 PUSH0 EQ PUSH2 @f0 JUMPI
+		DUP1 PUSH4 0x06fdde03 EQ PUSH2 @f1 JUMPI
+		PUSH0 DUP1 REVERT
+		f0: JUMPDEST STOP
+		f1: JUMPDEST STOP
+	`)
+	assertSelectors(t, ParseFunctionSelectors(code), "00000000", "06fdde03")
+
+	// A contract compiled with solc 0.8.25 pushes its zeros with PUSH0, none of them is a
+	// selector as it only has a fallback.
+	code = loadFixture(t, "solc-0.8.25-deposits.hex")
+	assertSelectors(t, ParseFunctionSelectors(code))
+	if compiler := Fingerprint(code); compiler.Version != "0.8.25" || !slices.Contains(compiler.Evidence, "PUSH0 instruction") {
+		t.Errorf("unexpected fingerprint %+v", compiler)
+	}
+}
+
+func TestParseVyperLinearDispatcher(t *testing.T) {
+	code := assemble(t, `
+		PUSH1 0x03 CALLDATASIZE GT PUSH2 @start JUMPI PUSH2 @fallback JUMP
+		start: JUMPDEST PUSH1 0x00 CALLDATALOAD PUSH1 0xe0 SHR
+		PUSH4 0xa9059cbb DUP2 XOR PUSH2 @next1 JUMPI
+		CALLVALUE PUSH2 @fallback JUMPI STOP
+		next1: JUMPDEST PUSH4 0x70a08231 DUP2 XOR PUSH2 @next2 JUMPI
+		STOP
+		next2: JUMPDEST PUSH2 0x1234 DUP2 XOR PUSH2 @fallback JUMPI
+		STOP
+		fallback: JUMPDEST PUSH1 0x00 DUP1 REVERT
+	`)
+	dispatcher := ParseDispatcher(code)
+	if dispatcher.Compiler != CompilerVyper {
+		t.Fatalf("wrong compiler detected: %s", dispatcher.Compiler)
+	}
+	assertSelectors(t, dispatcher.IDs(), "a9059cbb", "70a08231", "00001234")
+}
+
+func TestParseVyperSparseDispatcher(t *testing.T) {
+	code := assemble(t, `
+		PUSH1 0x03 CALLDATASIZE GT PUSH2 @start JUMPI PUSH2 @fallback JUMP
+		start: JUMPDEST PUSH1 0x00 CALLDATALOAD PUSH1 0xe0 SHR
+		PUSH1 0x02 PUSH1 0x02 DUP3 MOD PUSH1 0x01 SHL PUSH2 @table ADD PUSH1 0x1e CODECOPY
+		PUSH1 0x00 MLOAD JUMP
+		bucket0: JUMPDEST PUSH2 0x1234 DUP2 XOR PUSH2 @fallback JUMPI STOP
+		bucket1: JUMPDEST PUSH4 0xa9059cbb DUP2 XOR PUSH2 @next JUMPI STOP
+		next: JUMPDEST PUSH4 0x70a08231 DUP2 XOR PUSH2 @fallback JUMPI STOP
+		fallback: JUMPDEST PUSH1 0x00 DUP1 REVERT
+		table: @bucket0 @bucket1
+	`)
+	assertSelectors(t, ParseFunctionSelectors(code), "00001234", "a9059cbb", "70a08231")
+}
+
+func TestParseVyperDenseDispatcher(t *testing.T) {
+	code := assemble(t, `
+		PUSH1 0x03 CALLDATASIZE GT PUSH2 @start JUMPI PUSH2 @fallback JUMP
+		start: JUMPDEST PUSH1 0x00 CALLDATALOAD PUSH1 0xe0 SHR
+		PUSH1 0x05 PUSH1 0x02 DUP3 MOD PUSH1 0x05 MUL PUSH2 @headers ADD PUSH1 0x1b CODECOPY
+		PUSH1 0x00 MLOAD PUSH1 0x08 SHR JUMP
+		f1: JUMPDEST STOP
+		f2: JUMPDEST STOP
+		f3: JUMPDEST STOP
+		fallback: JUMPDEST PUSH1 0x00 DUP1 REVERT
+		headers: #0001 @bucket0 #01 #0002 @bucket1 #02
+		bucket0: #18160ddd @f1 #00
+		bucket1: #a9059cbb @f2 #01 #70a08231 @f3 #00
+	`)
+	dispatcher := ParseDispatcher(code)
+	assertSelectors(t, dispatcher.IDs(), "18160ddd", "a9059cbb", "70a08231")
+	for _, sel := range dispatcher.Selectors {
+		if code[sel.Entry] != byte(vm.JUMPDEST) {
+			t.Fatalf("selector %s entry %#x is not a jump destination", sel.ID, sel.Entry)
+		}
+	}
+}
+
+func TestParseViaIRDispatcher(t *testing.T) {
+	// Via-IR keeps the call value above the selector, so comparisons duplicate it from deeper slots.
+	code := assemble(t, `
+		PUSH1 0x80 PUSH1 0x40 MSTORE
+		PUSH1 0x04 CALLDATASIZE LT ISZERO PUSH2 @start JUMPI PUSH0 DUP1 REVERT
+		start: JUMPDEST PUSH0 CALLDATALOAD PUSH1 0xe0 SHR CALLVALUE
+		DUP2 PUSH4 0x06fdde03 EQ PUSH2 @f1 JUMPI
+		PUSH4 0x095ea7b3 DUP3 EQ PUSH2 @f2 JUMPI
+		PUSH4 0x18160ddd DUP3 SUB PUSH2 @next JUMPI
+		STOP
+		next: JUMPDEST PUSH0 DUP1 REVERT
+		f1: JUMPDEST STOP
+		f2: JUMPDEST STOP
+	`)
+	dispatcher := ParseDispatcher(code)
+	assertSelectors(t, dispatcher.IDs(), "06fdde03", "095ea7b3", "18160ddd")
+	assertSelectors(t, dispatcher.Patterns, "solc-viair-eq", "solc-viair-dup-eq", "solc-viair-sub")
+}
+
+func TestRegisterDispatcherPattern(t *testing.T) {
+	code := assemble(t, `
+		PUSH0 CALLDATALOAD PUSH1 0xe0 SHR
+		DUP1 PUSH4 0x06fdde03 EQ ISZERO PUSH2 @next JUMPI
+		STOP
+		next: JUMPDEST PUSH0 DUP1 REVERT
+	`)
+	assertSelectors(t, ParseFunctionSelectors(code))
+	patterns := DispatcherPatterns()
+	t.Cleanup(func() {
+		patternsMu.Lock()
+		dispatcherPatterns = patterns
+		patternsMu.Unlock()
+	})
+	RegisterDispatcherPattern(DispatcherPattern{
+		Name:   "test-eq-iszero",
+		Kind:   DispatchSkip,
+		Ops:    [][]vm.OpCode{{vm.DUP1}, {vm.PUSH4}, {vm.EQ}, {vm.ISZERO}, {vm.PUSH2}, {vm.JUMPI}},
+		Value:  1,
+		Target: 4,
+	})
+	assertSelectors(t, ParseFunctionSelectors(code), "06fdde03")
+	if compiler := DetectCompiler(code); compiler == CompilerVyper {
+		t.Errorf("generic pattern detected as %s", compiler)
+	}
+}
+
+func TestEmulateDispatcher(t *testing.T) {
+	// A hand-written dispatcher keeping the selector in memory and pushing the jump
+	// targets before the comparisons, which no dispatcher pattern matches.
+	code := assemble(t, `
+		PUSH0 CALLDATALOAD PUSH1 0xe0 SHR PUSH1 0x40 MSTORE
+		PUSH2 @f1 PUSH4 0x06fdde03 PUSH1 0x40 MLOAD EQ SWAP1 JUMPI
+		PUSH4 0x095ea7b3 PUSH1 0x40 MLOAD XOR ISZERO PUSH2 @f2 JUMPI
+		PUSH1 0x40 MLOAD PUSH4 0x80000000 GT PUSH2 @upper JUMPI
+		PUSH0 DUP1 REVERT
+		upper: JUMPDEST
+		PUSH4 0xa9059cbb PUSH1 0x40 MLOAD SUB PUSH2 @fallback JUMPI
+		STOP
+		fallback: JUMPDEST PUSH0 DUP1 REVERT
+		f1: JUMPDEST STOP
+		f2: JUMPDEST STOP
+	`)
+	assertSelectors(t, ParseFunctionSelectors(code))
+	dispatcher := EmulateDispatcher(code)
+	assertSelectors(t, dispatcher.IDs(), "06fdde03", "095ea7b3", "a9059cbb")
+	assertSelectors(t, dispatcher.Pivots, "80000000")
+}
+
+func TestEmulateDispatcherMatchesPatterns(t *testing.T) {
+	code := assemble(t, splitDispatcher)
+	assertSelectors(t, EmulateDispatcher(code).IDs(), ParseFunctionSelectors(code)...)
+}
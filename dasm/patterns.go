@@ -0,0 +1,113 @@
+package dasm
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// DispatchKind tells how a dispatcher pattern routes the execution.
+type DispatchKind int
+
+const (
+	DispatchCompare DispatchKind = iota // JUMPI to the function body when the selector equals the value
+	DispatchSkip                        // JUMPI to the next comparison when the selector differs from the value
+	DispatchPivot                       // JUMPI to one half of a binary-search dispatcher
+)
+
+// DispatcherPattern describes the instruction sequence ending a block of a function dispatcher.
+type DispatcherPattern struct {
+	Name     string        // unique name of the pattern, e.g. "solc-legacy-eq"
+	Compiler Compiler      // compiler family emitting the pattern
+	Kind     DispatchKind  // how the JUMPI ending the pattern routes the execution
+	Ops      [][]vm.OpCode // accepted opcodes for every instruction of the pattern
+	Value    int           // index of the PUSH holding the selector or pivot, -1 for the all-zero selector
+	Target   int           // index of the PUSH holding the jump target of the JUMPI
+	After    []vm.OpCode   // accepted opcodes of the instruction before the pattern in its block, any if empty
+}
+
+func (p *DispatcherPattern) matchers() []matcherFn {
+	fns := make([]matcherFn, len(p.Ops))
+	for i, ops := range p.Ops {
+		fns[i] = opAnyOf(ops...)
+	}
+	return fns
+}
+
+var (
+	pushSelectorOps = []vm.OpCode{vm.PUSH0, vm.PUSH1, vm.PUSH2, vm.PUSH3, vm.PUSH4}
+	pushTargetOps   = []vm.OpCode{vm.PUSH2, vm.PUSH3}
+	dupOps          = []vm.OpCode{vm.DUP1, vm.DUP2, vm.DUP3, vm.DUP4}
+	// extractSelectorOps compute the selector out of the loaded calldata.
+	extractSelectorOps = []vm.OpCode{vm.SHR, vm.DIV, vm.AND}
+)
+
+var (
+	patternsMu         sync.RWMutex
+	dispatcherPatterns = []DispatcherPattern{
+		// Legacy codegen keeps the selector on top of the stack and duplicates it for every comparison.
+		{Name: "solc-legacy-eq", Compiler: CompilerSolc, Kind: DispatchCompare, Value: 1, Target: 3,
+			Ops: [][]vm.OpCode{{vm.DUP1}, pushSelectorOps, {vm.EQ}, pushTargetOps, {vm.JUMPI}}},
+		{Name: "solc-legacy-gt", Compiler: CompilerSolc, Kind: DispatchPivot, Value: 1, Target: 3,
+			Ops: [][]vm.OpCode{{vm.DUP1}, pushSelectorOps, {vm.GT}, pushTargetOps, {vm.JUMPI}}},
+		// The optimizer rewrites `EQ(x, 0)` to `ISZERO(x)` for the all-zero selector. The
+		// duplicated value must be the selector, not e.g. the CALLVALUE of a non-payable check.
+		{Name: "solc-legacy-iszero", Compiler: CompilerSolc, Kind: DispatchCompare, Value: -1, Target: 2,
+			Ops: [][]vm.OpCode{{vm.DUP1}, {vm.ISZERO}, pushTargetOps, {vm.JUMPI}}, After: extractSelectorOps},
+		// The optimizer may push the selector first and duplicate the calldata selector over it.
+		{Name: "solc-optimized-eq", Compiler: CompilerSolc, Kind: DispatchCompare, Value: 0, Target: 3,
+			Ops: [][]vm.OpCode{pushSelectorOps, {vm.DUP2}, {vm.EQ}, pushTargetOps, {vm.JUMPI}}},
+		{Name: "solc-optimized-gt", Compiler: CompilerSolc, Kind: DispatchPivot, Value: 0, Target: 3,
+			Ops: [][]vm.OpCode{pushSelectorOps, {vm.DUP2}, {vm.GT, vm.LT}, pushTargetOps, {vm.JUMPI}}},
+		// Via-IR codegen may keep other values above the selector after its stack shuffling,
+		// so the selector is duplicated from deeper slots, and can branch on inequality.
+		{Name: "solc-viair-eq", Compiler: CompilerSolc, Kind: DispatchCompare, Value: 1, Target: 3,
+			Ops: [][]vm.OpCode{dupOps, pushSelectorOps, {vm.EQ}, pushTargetOps, {vm.JUMPI}}},
+		{Name: "solc-viair-dup-eq", Compiler: CompilerSolc, Kind: DispatchCompare, Value: 0, Target: 3,
+			Ops: [][]vm.OpCode{pushSelectorOps, dupOps, {vm.EQ}, pushTargetOps, {vm.JUMPI}}},
+		{Name: "solc-viair-sub", Compiler: CompilerSolc, Kind: DispatchSkip, Value: 0, Target: 3,
+			Ops: [][]vm.OpCode{pushSelectorOps, dupOps, {vm.SUB}, pushTargetOps, {vm.JUMPI}}},
+		{Name: "solc-viair-pivot", Compiler: CompilerSolc, Kind: DispatchPivot, Value: 1, Target: 3,
+			Ops: [][]vm.OpCode{dupOps, pushSelectorOps, {vm.GT, vm.LT}, pushTargetOps, {vm.JUMPI}}},
+		// Vyper jumps to the next comparison when the selector XOR the value is non-zero.
+		{Name: "vyper-xor", Compiler: CompilerVyper, Kind: DispatchSkip, Value: 0, Target: 3,
+			Ops: [][]vm.OpCode{pushSelectorOps, {vm.DUP1, vm.DUP2, vm.DUP3}, {vm.XOR}, pushTargetOps, {vm.JUMPI}}},
+		// Vyper before 0.3.0 keeps the selector in memory at offset 0.
+		{Name: "vyper-legacy-mload", Compiler: CompilerVyper, Kind: DispatchSkip, Value: 0, Target: 5,
+			Ops: [][]vm.OpCode{pushSelectorOps, {vm.PUSH1}, {vm.MLOAD}, {vm.EQ}, {vm.ISZERO}, pushTargetOps, {vm.JUMPI}}},
+	}
+)
+
+// RegisterDispatcherPattern adds a dispatcher pattern, patterns are tried in registration
+// order so the built-in patterns take precedence over the registered ones.
+func RegisterDispatcherPattern(p DispatcherPattern) {
+	patternsMu.Lock()
+	defer patternsMu.Unlock()
+	dispatcherPatterns = append(dispatcherPatterns, p)
+}
+
+// DispatcherPatterns returns the registered dispatcher patterns.
+func DispatcherPatterns() []DispatcherPattern {
+	patternsMu.RLock()
+	defer patternsMu.RUnlock()
+	return append([]DispatcherPattern{}, dispatcherPatterns...)
+}
+
+// compiledPattern is a dispatcher pattern with its matchers built.
+type compiledPattern struct {
+	DispatcherPattern
+	match []matcherFn
+}
+
+// patternsFor returns the dispatcher patterns emitted by the compiler family, patterns
+// registered without a family or for CompilerUnknown apply to every family. All patterns
+// are returned if the family is unknown.
+func patternsFor(compiler Compiler) []compiledPattern {
+	ret := make([]compiledPattern, 0)
+	for _, p := range DispatcherPatterns() {
+		if compiler == CompilerUnknown || p.Compiler == "" || p.Compiler == CompilerUnknown || p.Compiler == compiler {
+			ret = append(ret, compiledPattern{p, p.matchers()})
+		}
+	}
+	return ret
+}
@@ -0,0 +1,57 @@
+package dasm
+
+// reachableBlocks returns the start PCs of the blocks reachable from the roots following
+// the resolved edges and the jump destinations pushed by the reachable code, such as the
+// return addresses of internal functions which are jumped to dynamically.
+func reachableBlocks(g *CFG, roots []uint64) map[uint64]bool {
+	visited := make(map[uint64]bool)
+	queue := make([]uint64, 0, len(roots))
+	enqueue := func(pc uint64) {
+		if !visited[pc] && g.blocks[pc] != nil {
+			visited[pc] = true
+			queue = append(queue, pc)
+		}
+	}
+	for _, root := range roots {
+		enqueue(root)
+	}
+	for len(queue) > 0 {
+		block := g.blocks[queue[0]]
+		queue = queue[1:]
+		for _, succ := range block.Succs {
+			enqueue(succ)
+		}
+		for _, in := range block.Instructions {
+			if target, ok := pushTarget(in); ok && len(in.arg) <= 4 {
+				if dest := g.blocks[target]; dest != nil && dest.IsJumpDest() {
+					enqueue(target)
+				}
+			}
+		}
+	}
+	return visited
+}
+
+// SplitCode splits the bytecode in the code reachable from the entry and the trailing
+// data which can not be executed, such as embedded constants, immutables tables or the
+// constructor arguments appended to the init code. The function entries found in the
+// dispatcher are also treated as reachable as jump tables are read from the code.
+func SplitCode(bytecode []byte) (code []byte, data []byte) {
+	g := newCFG(NewInstructionIterator(bytecode))
+	if len(g.Blocks) == 0 {
+		return nil, bytecode
+	}
+	roots := []uint64{g.Blocks[0].Start}
+	for _, sel := range ParseDispatcher(bytecode).Selectors {
+		roots = append(roots, sel.Entry)
+	}
+	reachable := reachableBlocks(g, roots)
+	end := uint64(0)
+	for _, block := range g.Blocks {
+		if reachable[block.Start] {
+			last := block.Last()
+			end = last.pc + 1 + uint64(len(last.arg))
+		}
+	}
+	return bytecode[:end], bytecode[end:]
+}
@@ -0,0 +1,42 @@
+package dasm
+
+import (
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSplitCode(t *testing.T) {
+	// The return address of the internal function is only jumped to dynamically.
+	code := assemble(t, "PUSH2 @ret PUSH2 @fn JUMP ret: JUMPDEST STOP fn: JUMPDEST JUMP #deadbeef5b7f")
+	reachable, data := SplitCode(code)
+	if hex.EncodeToString(data) != "deadbeef5b7f" || len(reachable)+len(data) != len(code) {
+		t.Fatalf("unexpected data %x", data)
+	}
+
+	lines, err := Disassemble(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listing := strings.Join(lines, "")
+	if !strings.Contains(listing, "0000b: .data 0xdeadbeef5b7f\n") || strings.Contains(listing, "PUSH32") {
+		t.Errorf("unexpected disassembly:\n%s", listing)
+	}
+
+	// PrintDisassembled lists the same data region.
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	err = PrintDisassembled(hex.EncodeToString(code))
+	os.Stdout = stdout
+	w.Close()
+	printed, _ := io.ReadAll(r)
+	if err != nil || string(printed) != listing {
+		t.Errorf("unexpected printed disassembly %v:\n%s", err, printed)
+	}
+}
@@ -0,0 +1,131 @@
+package dasm
+
+import (
+	"sort"
+)
+
+// Report is the structured result of the analysis of a contract. Its JSON and YAML
+// encodings are a stable schema documented in the README, fields are only ever added.
+type Report struct {
+	Address    string            `json:"address,omitempty" yaml:"address,omitempty"`   // checksummed contract address, empty for offline bytecode
+	Format     string            `json:"format" yaml:"format"`                         // "legacy" or the EOF container layout
+	Metadata   *MetadataReport   `json:"metadata,omitempty" yaml:"metadata,omitempty"` // decoded CBOR metadata, nil if stripped
+	Compiler   CompilerReport    `json:"compiler" yaml:"compiler"`
+	Proxy      ProxyReport       `json:"proxy" yaml:"proxy"`
+	Creation   *CreationReport   `json:"creation,omitempty" yaml:"creation,omitempty"` // set when the runtime code was extracted from init code
+	Selectors  []SelectorReport  `json:"selectors" yaml:"selectors"`
+	Events     []EventReport     `json:"events" yaml:"events"`
+	Errors     []ErrorReport     `json:"errors" yaml:"errors"`
+	Reasons    []string          `json:"reasons" yaml:"reasons"` // Error(string) revert reasons found in the code
+	Interfaces []InterfaceReport `json:"interfaces" yaml:"interfaces"`
+	Warnings   []string          `json:"warnings,omitempty" yaml:"warnings,omitempty"` // failures of the signature sources, their ids are left unknown
+}
+
+type MetadataReport struct {
+	Compiler     string `json:"compiler" yaml:"compiler"`
+	Version      string `json:"version,omitempty" yaml:"version,omitempty"`
+	IPFS         string `json:"ipfs,omitempty" yaml:"ipfs,omitempty"`
+	Swarm        string `json:"swarm,omitempty" yaml:"swarm,omitempty"`
+	Experimental bool   `json:"experimental,omitempty" yaml:"experimental,omitempty"`
+}
+
+type CompilerReport struct {
+	Name      string `json:"name" yaml:"name"`           // solc, vyper, huff or unknown
+	Version   string `json:"version" yaml:"version"`     // exact version or version range, e.g. ">=0.8.20"
+	Optimizer string `json:"optimizer" yaml:"optimizer"` // optimizer settings, see CompilerInfo
+}
+
+type ProxyReport struct {
+	IsProxy        bool   `json:"isProxy" yaml:"isProxy"`
+	Implementation string `json:"implementation,omitempty" yaml:"implementation,omitempty"` // EIP-1967 implementation address
+}
+
+type CreationReport struct {
+	RuntimeOffset   uint64 `json:"runtimeOffset" yaml:"runtimeOffset"`
+	RuntimeSize     int    `json:"runtimeSize" yaml:"runtimeSize"`
+	ConstructorArgs string `json:"constructorArgs,omitempty" yaml:"constructorArgs,omitempty"` // hex encoded
+}
+
+type SelectorReport struct {
+	Selector        string   `json:"selector" yaml:"selector"`                                   // 4-bytes selector in hex
+	Signatures      []string `json:"signatures" yaml:"signatures"`                               // known signatures of the selector
+	Confidence      float64  `json:"confidence" yaml:"confidence"`                               // 1 if found by both the dispatcher patterns and the emulation, 0.5 by one of them, always 0.5 for EOF
+	Inferred        string   `json:"inferred,omitempty" yaml:"inferred,omitempty"`               // signature guessed from the calldata reads if none is known, e.g. "f(uint256,address)"
+	StateMutability string   `json:"stateMutability,omitempty" yaml:"stateMutability,omitempty"` // guessed from the function body: payable, nonpayable, view or pure
+}
+
+type EventReport struct {
+	Topic      string   `json:"topic" yaml:"topic"`           // 32-bytes event topic in hex
+	Signatures []string `json:"signatures" yaml:"signatures"` // known signatures of the topic
+}
+
+type ErrorReport struct {
+	Selector   string   `json:"selector" yaml:"selector"`     // 4-bytes custom error selector in hex
+	Signatures []string `json:"signatures" yaml:"signatures"` // known signatures of the error
+}
+
+type InterfaceReport struct {
+	Name          string  `json:"name" yaml:"name"`
+	Matched       int     `json:"matched" yaml:"matched"`             // number of functions and events of the interface found in the contract
+	Total         int     `json:"total" yaml:"total"`                 // number of functions and events of the interface
+	Confidence    float64 `json:"confidence" yaml:"confidence"`       // share of the interface elements found, 1 if fully implemented
+	ErrorsMatched int     `json:"errorsMatched" yaml:"errorsMatched"` // number of custom errors of the interface found, not part of the confidence
+}
+
+// NewMetadataReport returns the report of the decoded metadata.
+func NewMetadataReport(m *Metadata) *MetadataReport {
+	return &MetadataReport{
+		Compiler:     string(m.Compiler),
+		Version:      m.Version,
+		IPFS:         m.IPFS,
+		Swarm:        m.Swarm,
+		Experimental: m.Experimental,
+	}
+}
+
+// MatchInterfaces returns the interfaces having at least one of their elements or custom
+// errors in the ids, with the share of their elements found. Custom errors are often not
+// reachable by the revert analysis, so they do not count toward the confidence and only
+// rank the interfaces of equal confidence.
+func MatchInterfaces(interfaces []Interface, ids []string) []InterfaceReport {
+	found := make(map[string]bool)
+	for _, id := range ids {
+		found[id] = true
+	}
+	countFound := func(elements map[string]ABIElement) int {
+		count := 0
+		for id := range elements {
+			if found[id] {
+				count++
+			}
+		}
+		return count
+	}
+	ret := make([]InterfaceReport, 0)
+	for _, intf := range interfaces {
+		matched, errorsMatched := countFound(intf.Elements), countFound(intf.ErrorElements)
+		if matched == 0 && errorsMatched == 0 {
+			continue
+		}
+		report := InterfaceReport{
+			Name:          intf.Name,
+			Matched:       matched,
+			Total:         len(intf.Elements),
+			ErrorsMatched: errorsMatched,
+		}
+		if report.Total > 0 {
+			report.Confidence = float64(matched) / float64(report.Total)
+		}
+		ret = append(ret, report)
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Confidence != ret[j].Confidence {
+			return ret[i].Confidence > ret[j].Confidence
+		}
+		if ret[i].ErrorsMatched != ret[j].ErrorsMatched {
+			return ret[i].ErrorsMatched > ret[j].ErrorsMatched
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
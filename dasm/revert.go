@@ -0,0 +1,102 @@
+package dasm
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+var ErrEmptyRevert = errors.New("empty revert data")
+
+// panicReasons are the meanings of the Solidity Panic(uint256) codes.
+var panicReasons = map[uint64]string{
+	0x00: "generic compiler inserted panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic operation overflowed or underflowed",
+	0x12: "division or modulo by zero",
+	0x21: "conversion of a value out of range into an enum",
+	0x22: "access to an incorrectly encoded storage byte array",
+	0x31: "pop on an empty array",
+	0x32: "array index out of bounds",
+	0x41: "too much memory allocated or array too large",
+	0x51: "call to a zero-initialized variable of internal function type",
+}
+
+// DecodedRevert is revert data decoded against the builtin and the known custom errors.
+type DecodedRevert struct {
+	Signature string        // signature of the error, e.g. `Error(string)`
+	Inputs    abi.Arguments // arguments of the error
+	Values    []interface{} // decoded values of the arguments
+	Reason    string        // reason string of Error(string) or meaning of the Panic(uint256) code
+}
+
+func mustArguments(types ...string) abi.Arguments {
+	args := make(abi.Arguments, len(types))
+	for i, typ := range types {
+		args[i].Type, _ = abi.NewType(typ, "", nil)
+	}
+	return args
+}
+
+var (
+	errorStringArgs = mustArguments("string")
+	panicArgs       = mustArguments("uint256")
+)
+
+// PanicReason returns the meaning of a Solidity panic code.
+func PanicReason(code *big.Int) string {
+	if code.IsUint64() {
+		if reason, ok := panicReasons[code.Uint64()]; ok {
+			return reason
+		}
+	}
+	return fmt.Sprintf("unknown panic code %#x", code)
+}
+
+// DecodeRevert decodes the data returned by a reverted call: `Error(string)`,
+// `Panic(uint256)` or a custom error of the interfaces. Custom errors sharing the
+// selector are tried in turn until one decodes the data.
+func DecodeRevert(data []byte, interfaces []Interface) (*DecodedRevert, error) {
+	if len(data) == 0 {
+		return nil, ErrEmptyRevert
+	}
+	if len(data) < 4 {
+		return nil, fmt.Errorf("revert data too short: %#x", data)
+	}
+	selector := hex.EncodeToString(data[:4])
+	switch selector {
+	case errorStringSelector:
+		values, err := errorStringArgs.Unpack(data[4:])
+		if err != nil {
+			return nil, fmt.Errorf("could not decode Error(string): %w", err)
+		}
+		return &DecodedRevert{Signature: "Error(string)", Inputs: errorStringArgs, Values: values, Reason: values[0].(string)}, nil
+	case panicSelectorHex:
+		values, err := panicArgs.Unpack(data[4:])
+		if err != nil {
+			return nil, fmt.Errorf("could not decode Panic(uint256): %w", err)
+		}
+		return &DecodedRevert{Signature: "Panic(uint256)", Inputs: panicArgs, Values: values, Reason: PanicReason(values[0].(*big.Int))}, nil
+	}
+	var lastErr error
+	for _, intf := range interfaces {
+		elem, ok := intf.ErrorElements[selector]
+		if !ok {
+			continue
+		}
+		inputs := abi.Arguments(elem.Inputs)
+		values, err := inputs.Unpack(data[4:])
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return &DecodedRevert{Signature: elem.Identifier(), Inputs: inputs, Values: values}, nil
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("could not decode error %s: %w", selector, lastErr)
+	}
+	return nil, fmt.Errorf("unknown error selector %s", selector)
+}
@@ -0,0 +1,53 @@
+package dasm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDecodeRevert(t *testing.T) {
+	reason := common.FromHex("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000005" +
+		"68656c6c6f000000000000000000000000000000000000000000000000000000")
+	decoded, err := DecodeRevert(reason, nil)
+	if err != nil || decoded.Signature != "Error(string)" || decoded.Reason != "hello" {
+		t.Errorf("unexpected Error(string) %+v: %v", decoded, err)
+	}
+
+	// Output of delegate(address) of the Ballot fixture called by the delegate itself.
+	recorded := common.FromHex("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"000000000000000000000000000000000000000000000000000000000000001e" +
+		"53656c662d64656c65676174696f6e20697320646973616c6c6f7765642e0000")
+	decoded, err = DecodeRevert(recorded, nil)
+	if err != nil || decoded.Reason != "Self-delegation is disallowed." {
+		t.Errorf("unexpected recorded Error(string) %+v: %v", decoded, err)
+	}
+
+	panicData := common.FromHex("0x4e487b71" + "0000000000000000000000000000000000000000000000000000000000000032")
+	decoded, err = DecodeRevert(panicData, nil)
+	if err != nil || decoded.Signature != "Panic(uint256)" || decoded.Reason != "array index out of bounds" {
+		t.Errorf("unexpected Panic(uint256) %+v: %v", decoded, err)
+	}
+
+	intf := loadInterface(t, "Errors", `[{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}]`)
+	custom, err := intf.Errors["InsufficientBalance"].Inputs.Pack(big.NewInt(1), big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom = append(common.FromHex(FourBytesSigOf("InsufficientBalance(uint256,uint256)")), custom...)
+	decoded, err = DecodeRevert(custom, []Interface{intf})
+	if err != nil || decoded.Signature != "InsufficientBalance(uint256,uint256)" || decoded.Values[1].(*big.Int).Int64() != 2 {
+		t.Errorf("unexpected custom error %+v: %v", decoded, err)
+	}
+
+	if _, err := DecodeRevert(common.FromHex("0xdeadbeef"), []Interface{intf}); err == nil {
+		t.Error("expected error for unknown selector")
+	}
+	if _, err := DecodeRevert(nil, nil); err != ErrEmptyRevert {
+		t.Errorf("expected ErrEmptyRevert, got %v", err)
+	}
+}
@@ -0,0 +1,500 @@
+package dasm
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	_ "embed"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/exp/maps"
+)
+
+//go:generate go run gen_signatures.go
+
+// SignatureKind is the kind of the elements of a signature database.
+type SignatureKind byte
+
+const (
+	KindFunction SignatureKind = iota
+	KindEvent
+	KindError
+)
+
+func (k SignatureKind) String() string {
+	switch k {
+	case KindFunction:
+		return "function"
+	case KindEvent:
+		return "event"
+	case KindError:
+		return "error"
+	}
+	return fmt.Sprintf("kind(%d)", k)
+}
+
+// idSize returns the size of the ids of the kind, 4-bytes selectors or 32-bytes topics.
+func (k SignatureKind) idSize() int {
+	if k == KindEvent {
+		return 32
+	}
+	return 4
+}
+
+var signatureKinds = []SignatureKind{KindFunction, KindEvent, KindError}
+
+// signatureKindOf returns the kind named name, functions by default.
+func signatureKindOf(name string) SignatureKind {
+	for _, kind := range signatureKinds {
+		if kind.String() == name {
+			return kind
+		}
+	}
+	return KindFunction
+}
+
+func (k SignatureKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *SignatureKind) UnmarshalText(text []byte) error {
+	for _, kind := range signatureKinds {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown signature kind %q", text)
+}
+
+// SignatureEntry is a signature of a database with its selector or topic.
+type SignatureEntry struct {
+	Kind      SignatureKind `json:"kind"`
+	ID        string        `json:"id"`
+	Signature string        `json:"signature"`
+}
+
+// sigdbMagic starts the signature database files, followed by the format version.
+var sigdbMagic = []byte("SIGDB\x01")
+
+var ErrInvalidSignatureDB = errors.New("invalid signature database")
+
+//go:embed signatures.db
+var bundledSignatures []byte
+
+var (
+	bundledOnce sync.Once
+	bundledDB   *SignatureDB
+)
+
+// BundledSignatureDB returns the signature database embedded in the binary, made of the
+// signatures of the common standards and protocols.
+func BundledSignatureDB() *SignatureDB {
+	bundledOnce.Do(func() {
+		db, err := ReadSignatureDB(bytes.NewReader(bundledSignatures))
+		if err != nil {
+			panic(fmt.Errorf("could not load bundled signature database: %w", err))
+		}
+		bundledDB = db
+	})
+	return bundledDB
+}
+
+// SignatureDB is an offline database of the text signatures of functions, events and
+// custom errors indexed by their selector or topic in hex.
+type SignatureDB struct {
+	ids map[SignatureKind]map[string][]string
+}
+
+func NewSignatureDB() *SignatureDB {
+	db := &SignatureDB{ids: make(map[SignatureKind]map[string][]string)}
+	for _, kind := range signatureKinds {
+		db.ids[kind] = make(map[string][]string)
+	}
+	return db
+}
+
+// SignatureID returns the hex selector or topic of the signature.
+func SignatureID(kind SignatureKind, sig string) string {
+	if kind == KindEvent {
+		return crypto.Keccak256Hash([]byte(sig)).Hex()[2:]
+	}
+	return FourBytesSigOf(sig)
+}
+
+// Add adds a canonical signature, it returns false if the signature is already known.
+func (db *SignatureDB) Add(kind SignatureKind, sig string) bool {
+	id := SignatureID(kind, sig)
+	for _, known := range db.ids[kind][id] {
+		if known == sig {
+			return false
+		}
+	}
+	db.ids[kind][id] = append(db.ids[kind][id], sig)
+	return true
+}
+
+// Merge adds the signatures of another database, it returns the number of new signatures.
+func (db *SignatureDB) Merge(other *SignatureDB) int {
+	added := 0
+	for _, kind := range signatureKinds {
+		for _, sigs := range other.ids[kind] {
+			for _, sig := range sigs {
+				if db.Add(kind, sig) {
+					added++
+				}
+			}
+		}
+	}
+	return added
+}
+
+// Len returns the number of signatures of the kind.
+func (db *SignatureDB) Len(kind SignatureKind) int {
+	count := 0
+	for _, sigs := range db.ids[kind] {
+		count += len(sigs)
+	}
+	return count
+}
+
+// Signatures returns the sorted signatures of the kind matching the id.
+func (db *SignatureDB) Signatures(kind SignatureKind, id string) []string {
+	sigs := append([]string{}, db.ids[kind][strings.ToLower(strings.TrimPrefix(id, "0x"))]...)
+	sort.Strings(sigs)
+	return sigs
+}
+
+// Entries returns the signatures of the database sorted by kind, id and signature.
+func (db *SignatureDB) Entries() []SignatureEntry {
+	entries := make([]SignatureEntry, 0)
+	for _, kind := range signatureKinds {
+		ids := maps.Keys(db.ids[kind])
+		sort.Strings(ids)
+		for _, id := range ids {
+			for _, sig := range db.Signatures(kind, id) {
+				entries = append(entries, SignatureEntry{Kind: kind, ID: "0x" + id, Signature: sig})
+			}
+		}
+	}
+	return entries
+}
+
+func (db *SignatureDB) FunctionSignatures(ctx context.Context, selector string) ([]string, error) {
+	return db.Signatures(KindFunction, selector), nil
+}
+
+func (db *SignatureDB) EventSignatures(ctx context.Context, topic string) ([]string, error) {
+	return db.Signatures(KindEvent, topic), nil
+}
+
+func (db *SignatureDB) ErrorSignatures(ctx context.Context, selector string) ([]string, error) {
+	return db.Signatures(KindError, selector), nil
+}
+
+// WriteTo writes the database in its compact format: the gzip compressed list of the
+// binary ids and the signatures of each kind, sorted by id.
+func (db *SignatureDB) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	buf.Write(sigdbMagic)
+	for _, kind := range signatureKinds {
+		ids := maps.Keys(db.ids[kind])
+		sort.Strings(ids)
+		buf.Write(binary.AppendUvarint(nil, uint64(db.Len(kind))))
+		for _, id := range ids {
+			key, _ := hex.DecodeString(id)
+			for _, sig := range db.Signatures(kind, id) {
+				buf.Write(key)
+				buf.Write(binary.AppendUvarint(nil, uint64(len(sig))))
+				buf.WriteString(sig)
+			}
+		}
+	}
+	counter := &countingWriter{w: w}
+	zw := gzip.NewWriter(counter)
+	if _, err := zw.Write(buf.Bytes()); err != nil {
+		return counter.n, err
+	}
+	err := zw.Close()
+	return counter.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ReadSignatureDB reads a database written by WriteTo.
+func ReadSignatureDB(r io.Reader) (*SignatureDB, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignatureDB, err)
+	}
+	br := bufio.NewReader(zr)
+	magic := make([]byte, len(sigdbMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || !bytes.Equal(magic, sigdbMagic) {
+		return nil, ErrInvalidSignatureDB
+	}
+	db := NewSignatureDB()
+	for _, kind := range signatureKinds {
+		count, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSignatureDB, err)
+		}
+		key := make([]byte, kind.idSize())
+		for i := uint64(0); i < count; i++ {
+			if _, err := io.ReadFull(br, key); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidSignatureDB, err)
+			}
+			size, err := binary.ReadUvarint(br)
+			if err != nil || size > 4096 {
+				return nil, ErrInvalidSignatureDB
+			}
+			sig := make([]byte, size)
+			if _, err := io.ReadFull(br, sig); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidSignatureDB, err)
+			}
+			id := hex.EncodeToString(key)
+			db.ids[kind][id] = append(db.ids[kind][id], string(sig))
+		}
+	}
+	return db, nil
+}
+
+// LoadSignatureDB reads a database file.
+func LoadSignatureDB(fileName string) (*SignatureDB, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSignatureDB(f)
+}
+
+// SaveSignatureDB writes the database to a file.
+func SaveSignatureDB(db *SignatureDB, fileName string) error {
+	var buf bytes.Buffer
+	if _, err := db.WriteTo(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, buf.Bytes(), 0644)
+}
+
+var (
+	dumpKindRe      = regexp.MustCompile(`^\s*"?(function|event|error)\b`)
+	dumpSignatureRe = regexp.MustCompile(`[A-Za-z_$][A-Za-z0-9_$]*\([^\s"']*\)`)
+	dumpHashRe      = regexp.MustCompile(`0x(?:[0-9a-fA-F]{64}|[0-9a-fA-F]{8})\b`)
+)
+
+var errUnknownFormat = errors.New("unknown signature file format")
+
+// importSignature adds a signature read from a dump, the kind is taken from the hash
+// if any, and the signatures not matching their hash or not parsable are skipped.
+func (db *SignatureDB) importSignature(kind SignatureKind, sig string, hash string) bool {
+	if hash != "" {
+		hash = strings.ToLower(strings.TrimPrefix(hash, "0x"))
+		if len(hash) == 64 {
+			kind = KindEvent
+		} else if kind == KindEvent {
+			kind = KindFunction
+		}
+		if SignatureID(kind, sig) != hash {
+			return false
+		}
+	}
+	if _, _, err := ParseSignature(sig); err != nil {
+		return false
+	}
+	return db.Add(kind, sig)
+}
+
+// AddABI adds the functions, events and custom errors of an ABI, it returns the number
+// of new signatures.
+func (db *SignatureDB) AddABI(elems []ABIElement) int {
+	added := 0
+	for _, elem := range elems {
+		kind := KindFunction
+		switch elem.Type {
+		case "function":
+		case "event":
+			kind = KindEvent
+		case "error":
+			kind = KindError
+		default:
+			continue
+		}
+		if db.Add(kind, elem.Identifier()) {
+			added++
+		}
+	}
+	return added
+}
+
+// importJSON adds the signatures of a JSON document: a page of the 4byte.directory API,
+// an openchain lookup response, a list exported from a database, an ABI or a Foundry or
+// Hardhat artifact holding one.
+func (db *SignatureDB) importJSON(data []byte, kind SignatureKind) (int, error) {
+	added := 0
+	if data[0] == '{' {
+		var doc struct {
+			ABI     json.RawMessage `json:"abi"`
+			Results []struct {
+				TextSignature string `json:"text_signature"`
+				HexSignature  string `json:"hex_signature"`
+			} `json:"results"`
+			Result openchainResults `json:"result"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return 0, err
+		}
+		if doc.ABI != nil {
+			return db.importJSON(doc.ABI, kind)
+		}
+		if doc.Result != nil {
+			for resultKind, ids := range doc.Result {
+				for id, sigs := range ids {
+					for _, sig := range sigs {
+						if db.importSignature(signatureKindOf(resultKind), sig.Name, id) {
+							added++
+						}
+					}
+				}
+			}
+			return added, nil
+		}
+		if doc.Results == nil {
+			return 0, errUnknownFormat
+		}
+		for _, item := range doc.Results {
+			if db.importSignature(kind, item.TextSignature, item.HexSignature) {
+				added++
+			}
+		}
+		return added, nil
+	}
+	var entries []SignatureEntry
+	if err := json.Unmarshal(data, &entries); err == nil && len(entries) > 0 && entries[0].Signature != "" {
+		for _, entry := range entries {
+			if db.importSignature(entry.Kind, entry.Signature, entry.ID) {
+				added++
+			}
+		}
+		return added, nil
+	}
+	elems := make([]ABIElement, 0)
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return 0, err
+	}
+	return db.AddABI(elems), nil
+}
+
+// ImportDump adds the signatures of a 4byte.directory or openchain dump, it returns the
+// number of new signatures. Dumps are either JSON, the pages of the 4byte.directory API,
+// openchain lookup responses, ABIs or lists exported from a database, or text with a
+// signature per line, optionally with its hash or preceded by its kind, e.g.
+// `0xa9059cbb,transfer(address,uint256)` or `event Transfer(address,address,uint256)`.
+// The signatures without hash nor kind are of the given kind.
+func (db *SignatureDB) ImportDump(r io.Reader, kind SignatureKind) (int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		added, err := db.importJSON(trimmed, kind)
+		if err != nil {
+			return added, fmt.Errorf("could not parse signature dump: %w", err)
+		}
+		return added, nil
+	}
+	added := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		sig := dumpSignatureRe.FindString(line)
+		if sig == "" {
+			continue
+		}
+		lineKind := kind
+		if match := dumpKindRe.FindStringSubmatch(line); match != nil {
+			lineKind = signatureKindOf(match[1])
+		}
+		if db.importSignature(lineKind, sig, dumpHashRe.FindString(line)) {
+			added++
+		}
+	}
+	return added, scanner.Err()
+}
+
+// ImportFile adds the signatures of a dump, of another database or of the JSON files of
+// a directory such as the `out/` directory of Foundry or the `artifacts/` directory of
+// Hardhat, it returns the number of new signatures and of the JSON files of the directory
+// skipped because they could not be parsed.
+func (db *SignatureDB) ImportFile(fileName string) (int, int, error) {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return 0, 0, err
+	}
+	if info.IsDir() {
+		added, skipped := 0, 0
+		err := filepath.WalkDir(fileName, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() || filepath.Ext(path) != ".json" {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			data = bytes.TrimSpace(data)
+			if len(data) == 0 || (data[0] != '{' && data[0] != '[') {
+				return nil
+			}
+			count, err := db.importJSON(data, KindFunction)
+			if errors.Is(err, errUnknownFormat) {
+				// Build infos, debug files and other JSON files without ABI.
+				return nil
+			}
+			if err != nil {
+				// A broken or foreign JSON file does not stop the import of the others.
+				skipped++
+				return nil
+			}
+			added += count
+			return nil
+		})
+		return added, skipped, err
+	}
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return 0, 0, err
+	}
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		other, err := ReadSignatureDB(bytes.NewReader(data))
+		if err != nil {
+			return 0, 0, err
+		}
+		return db.Merge(other), 0, nil
+	}
+	added, err := db.ImportDump(bytes.NewReader(data), KindFunction)
+	return added, 0, err
+}
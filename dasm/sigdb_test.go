@@ -0,0 +1,133 @@
+package dasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBundledSignatureDB(t *testing.T) {
+	db := BundledSignatureDB()
+	if sigs, _ := db.FunctionSignatures(context.Background(), "313ce567"); !reflect.DeepEqual(sigs, []string{"decimals()"}) {
+		t.Errorf("unexpected signatures of 313ce567 %v", sigs)
+	}
+	if sigs, _ := db.EventSignatures(context.Background(), "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"); !reflect.DeepEqual(sigs, []string{"Transfer(address,address,uint256)"}) {
+		t.Errorf("unexpected signatures of the Transfer topic %v", sigs)
+	}
+	if sigs, _ := db.ErrorSignatures(context.Background(), "e450d38c"); !reflect.DeepEqual(sigs, []string{"ERC20InsufficientBalance(address,uint256,uint256)"}) {
+		t.Errorf("unexpected signatures of e450d38c %v", sigs)
+	}
+}
+
+func TestSignatureDBImportDump(t *testing.T) {
+	db := NewSignatureDB()
+	fourByte := `{"count":2,"results":[
+		{"id":1,"text_signature":"transfer(address,uint256)","hex_signature":"0xa9059cbb"},
+		{"id":2,"text_signature":"decimals()","hex_signature":"0xdeadbeef"}]}`
+	if added, err := db.ImportDump(strings.NewReader(fourByte), KindFunction); err != nil || added != 1 {
+		t.Fatalf("unexpected 4byte import %d: %v", added, err)
+	}
+	openchain := strings.Join([]string{
+		"0xa9059cbb,transfer(address,uint256)",
+		"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef,Transfer(address,address,uint256)",
+		"error ERC20InvalidSender(address)",
+		"balanceOf(address)",
+		"not a signature",
+		"0x12345678,approve(address,uint256)",
+	}, "\n")
+	if added, err := db.ImportDump(strings.NewReader(openchain), KindFunction); err != nil || added != 3 {
+		t.Fatalf("unexpected openchain import %d: %v", added, err)
+	}
+	if db.Len(KindFunction) != 2 || db.Len(KindEvent) != 1 || db.Len(KindError) != 1 {
+		t.Errorf("unexpected database sizes %d %d %d", db.Len(KindFunction), db.Len(KindEvent), db.Len(KindError))
+	}
+
+	var buf bytes.Buffer
+	if _, err := db.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadSignatureDB(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.ids, db.ids) {
+		t.Errorf("database changed by a round trip: %v != %v", loaded.ids, db.ids)
+	}
+	if _, err := ReadSignatureDB(strings.NewReader("garbage")); err == nil {
+		t.Error("expected an error reading an invalid database")
+	}
+}
+
+func TestSignatureDBImportOpenchain(t *testing.T) {
+	// Response of https://api.openchain.xyz/signature-database/v1/lookup?function=0x313ce567,0xdeadbeef&event=0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef
+	response := `{"ok":true,"result":{"event":{"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef":[{"name":"Transfer(address,address,uint256)","filtered":false}]},"function":{"0x313ce567":[{"name":"decimals()","filtered":false},{"name":"available_assert_time(uint16,uint64)","filtered":true}],"0xdeadbeef":null}}}`
+	db := NewSignatureDB()
+	if added, err := db.ImportDump(strings.NewReader(response), KindFunction); err != nil || added != 3 {
+		t.Fatalf("unexpected openchain import %d: %v", added, err)
+	}
+	if sigs := db.Signatures(KindFunction, "313ce567"); !reflect.DeepEqual(sigs, []string{"available_assert_time(uint16,uint64)", "decimals()"}) {
+		t.Errorf("unexpected function signatures %v", sigs)
+	}
+	if sigs := db.Signatures(KindEvent, "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"); len(sigs) != 1 {
+		t.Errorf("unexpected event signatures %v", sigs)
+	}
+}
+
+func TestSignatureDBImportFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		// Foundry artifact and build info.
+		"out/Vault.sol/Vault.json": `{"abi":[
+			{"type":"function","name":"sweep","inputs":[{"name":"to","type":"address"},{"name":"o","type":"tuple","components":[{"name":"a","type":"uint256"},{"name":"b","type":"bytes32[]"}]}]},
+			{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256"}]},
+			{"type":"error","name":"NotKeeper","inputs":[{"name":"who","type":"address"}]},
+			{"type":"constructor","inputs":[]}],"bytecode":{"object":"0x"}}`,
+		"out/build-info/1.json": `{"id":"1","output":{"contracts":{}}}`,
+		// JSON files which are not signature files at all.
+		"out/cache/ids.json":    `[1, 2, 3]`,
+		"out/cache/broken.json": `{"abi": [`,
+		// Hardhat artifact and debug file.
+		"artifacts/contracts/Pool.sol/Pool.json":     `{"_format":"hh-sol-artifact-1","contractName":"Pool","abi":[{"type":"function","name":"flash","inputs":[{"name":"a","type":"uint256"}]}]}`,
+		"artifacts/contracts/Pool.sol/Pool.dbg.json": `{"_format":"hh-sol-dbg-1","buildInfo":"../build-info/1.json"}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db := NewSignatureDB()
+	if added, skipped, err := db.ImportFile(filepath.Join(dir, "out")); err != nil || added != 3 || skipped != 2 {
+		t.Fatalf("unexpected Foundry import %d, %d skipped: %v", added, skipped, err)
+	}
+	if added, skipped, err := db.ImportFile(filepath.Join(dir, "artifacts")); err != nil || added != 1 || skipped != 0 {
+		t.Fatalf("unexpected Hardhat import %d, %d skipped: %v", added, skipped, err)
+	}
+	if sigs := db.Signatures(KindFunction, "4e1a47d6"); !reflect.DeepEqual(sigs, []string{"sweep(address,(uint256,bytes32[]))"}) {
+		t.Errorf("unexpected tuple signatures %v", sigs)
+	}
+	if sigs := db.Signatures(KindError, FourBytesSigOf("NotKeeper(address)")); len(sigs) != 1 {
+		t.Errorf("unexpected error signatures %v", sigs)
+	}
+
+	exported, err := json.Marshal(db.Entries())
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported := NewSignatureDB()
+	if added, err := imported.ImportDump(bytes.NewReader(exported), KindFunction); err != nil || added != 4 {
+		t.Fatalf("unexpected import of the export %d: %v", added, err)
+	}
+	if !reflect.DeepEqual(imported.ids, db.ids) {
+		t.Errorf("database changed by an export: %v != %v", imported.ids, db.ids)
+	}
+}
@@ -21,9 +21,10 @@ func FourBytesSigOf(sig string) string {
 type privateABI = abi.ABI
 
 type Interface struct {
-	privateABI                       // embedded abi struct
-	Name       string                // interface name
-	Elements   map[string]ABIElement // map from 4-bytes to abi element
+	privateABI                          // embedded abi struct
+	Name          string                // interface name
+	Elements      map[string]ABIElement // map from 4-bytes to abi element
+	ErrorElements map[string]ABIElement // map from 4-bytes to custom error element
 }
 
 func (intf *Interface) UnpackInput(v interface{}, name string, data []byte) error {
@@ -45,31 +46,42 @@ func (intf *Interface) UnpackInput(v interface{}, name string, data []byte) erro
 }
 
 func NewInterface(name string, elems []ABIElement) (Interface, error) {
-	methods := make(map[string]abi.Method)
-	events := make(map[string]abi.Event)
+	contractABI := abi.ABI{
+		Methods: make(map[string]abi.Method),
+		Events:  make(map[string]abi.Event),
+		Errors:  make(map[string]abi.Error),
+	}
 	elements := make(map[string]ABIElement)
+	errorElements := make(map[string]ABIElement)
 	for _, item := range elems {
 		switch item.Type {
 		case "function":
-			name := abi.ResolveNameConflict(item.Name, func(s string) bool { _, ok := methods[s]; return ok })
-			methods[name] = abi.NewMethod(name, item.Name, abi.Function, item.StateMutability, false, false, item.Inputs, item.Outputs)
+			name := abi.ResolveNameConflict(item.Name, func(s string) bool { _, ok := contractABI.Methods[s]; return ok })
+			contractABI.Methods[name] = abi.NewMethod(name, item.Name, abi.Function, item.StateMutability, false, false, item.Inputs, item.Outputs)
 			elements[common.Bytes2Hex(crypto.Keccak256([]byte(item.Identifier()))[:4])] = item
 		case "event":
-			name := abi.ResolveNameConflict(item.Name, func(s string) bool { _, ok := events[s]; return ok })
-			events[name] = abi.NewEvent(name, item.Name, item.Anonymous, item.Inputs)
+			name := abi.ResolveNameConflict(item.Name, func(s string) bool { _, ok := contractABI.Events[s]; return ok })
+			contractABI.Events[name] = abi.NewEvent(name, item.Name, item.Anonymous, item.Inputs)
 			elements[common.Bytes2Hex(crypto.Keccak256([]byte(item.Identifier())))] = item
 		case "constructor":
+			contractABI.Constructor = abi.NewMethod("", "", abi.Constructor, item.StateMutability, false, false, item.Inputs, nil)
 		case "fallback":
+			contractABI.Fallback = abi.NewMethod("", "", abi.Fallback, item.StateMutability, false, false, nil, nil)
 		case "receive":
+			contractABI.Receive = abi.NewMethod("", "", abi.Receive, item.StateMutability, false, false, nil, nil)
 		case "error":
+			// Errors cannot be overloaded, the name is unique.
+			contractABI.Errors[item.Name] = abi.NewError(item.Name, item.Inputs)
+			errorElements[common.Bytes2Hex(crypto.Keccak256([]byte(item.Identifier()))[:4])] = item
 		default:
 			return Interface{}, fmt.Errorf("invalid abi entry type: %v", item.Type)
 		}
 	}
 	return Interface{
-		privateABI: abi.ABI{Methods: methods, Events: events},
-		Name:       name,
-		Elements:   elements,
+		privateABI:    contractABI,
+		Name:          name,
+		Elements:      elements,
+		ErrorElements: errorElements,
 	}, nil
 }
 
@@ -105,6 +117,23 @@ type ABIElement struct {
 	Anonymous bool
 }
 
+// argumentOf returns the ABI JSON form of an argument, tuples are described by their components.
+func argumentOf(name string, typ abi.Type, indexed bool) argumentMarshaling {
+	arg := argumentMarshaling{Name: name, Type: typ.String(), InternalType: typ.String(), Indexed: indexed}
+	switch typ.T {
+	case abi.TupleTy:
+		arg.Type, arg.InternalType = "tuple", ""
+		for i, elem := range typ.TupleElems {
+			arg.Components = append(arg.Components, argumentOf(typ.TupleRawNames[i], *elem, false))
+		}
+	case abi.SliceTy, abi.ArrayTy:
+		if elem := argumentOf("", *typ.Elem, false); elem.Components != nil {
+			arg.Type, arg.InternalType, arg.Components = elem.Type+typ.String()[len(typ.Elem.String()):], "", elem.Components
+		}
+	}
+	return arg
+}
+
 func (e *ABIElement) MarshalJSON() ([]byte, error) {
 	marshaling := abiEntryMarshaling{
 		Type:            e.Type,
@@ -113,20 +142,10 @@ func (e *ABIElement) MarshalJSON() ([]byte, error) {
 		Anonymous:       e.Anonymous,
 	}
 	for _, arg := range e.Inputs {
-		marshaling.Inputs = append(marshaling.Inputs, argumentMarshaling{
-			Name:         arg.Name,
-			Type:         arg.Type.String(),
-			InternalType: arg.Type.String(),
-			Indexed:      arg.Indexed,
-		})
+		marshaling.Inputs = append(marshaling.Inputs, argumentOf(arg.Name, arg.Type, arg.Indexed))
 	}
 	for _, arg := range e.Outputs {
-		marshaling.Outputs = append(marshaling.Outputs, argumentMarshaling{
-			Name:         arg.Name,
-			Type:         arg.Type.String(),
-			InternalType: arg.Type.String(),
-			Indexed:      arg.Indexed,
-		})
+		marshaling.Outputs = append(marshaling.Outputs, argumentOf(arg.Name, arg.Type, arg.Indexed))
 	}
 	return json.Marshal(marshaling)
 }
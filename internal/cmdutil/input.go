@@ -0,0 +1,85 @@
+// Package cmdutil holds the input handling shared by the commands.
+package cmdutil
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/khanghh/contract-info/dasm"
+)
+
+// ErrNoInput is returned when no input is given nor piped to the standard input.
+var ErrNoInput = errors.New("no input given")
+
+// IsStdinPiped tells whether the standard input is a pipe or a file rather than a terminal.
+func IsStdinPiped() bool {
+	stat, err := os.Stdin.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice == 0
+}
+
+// ReadArgOrStdin returns the argument if set, otherwise the data piped to the standard input.
+func ReadArgOrStdin(arg string) ([]byte, error) {
+	if arg != "" {
+		return []byte(arg), nil
+	}
+	if !IsStdinPiped() {
+		return nil, ErrNoInput
+	}
+	return io.ReadAll(os.Stdin)
+}
+
+// ReadFileOrStdin reads the file, or the standard input if the file name is "-".
+func ReadFileOrStdin(fileName string) ([]byte, error) {
+	if fileName == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(fileName)
+}
+
+// DecodeHex decodes hex data pasted from JSON-RPC results, quoted or not and with an
+// optional 0x prefix.
+func DecodeHex(input []byte) ([]byte, error) {
+	text := strings.Trim(strings.TrimSpace(string(input)), `"'`)
+	return hex.DecodeString(strings.TrimPrefix(text, "0x"))
+}
+
+// BytecodeInput tells where to read offline bytecode from, in order of precedence.
+type BytecodeInput struct {
+	Hex   string // bytecode in hex given on the command line
+	File  string // file holding the bytecode, "-" for the standard input
+	Stdin bool   // read the bytecode piped to the standard input if neither is set
+}
+
+// ReadBytecode reads the offline bytecode, it returns nil if none is given and the
+// bytecode has to be fetched from the RPC. Bytecode given on the command line or piped
+// to the standard input must be hex, files may also hold the binary code.
+func ReadBytecode(in BytecodeInput) ([]byte, error) {
+	var (
+		data   []byte
+		err    error
+		decode = DecodeHex
+	)
+	switch {
+	case in.Hex != "":
+		data = []byte(in.Hex)
+	case in.File != "":
+		data, err = ReadFileOrStdin(in.File)
+		decode = dasm.DecodeBytecode
+	case in.Stdin && IsStdinPiped():
+		data, err = io.ReadAll(os.Stdin)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read bytecode: %w", err)
+	}
+	code, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bytecode: %w", err)
+	}
+	return code, nil
+}